@@ -0,0 +1,35 @@
+package backoff
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Backoff calculates exponentially growing delays between attempts with random jitter,
+// so clients which failed at the same time don't retry at the same time.
+type Backoff struct {
+	// Delay after the first failed attempt.
+	Min time.Duration
+	// Delays never grow above the maximum.
+	Max time.Duration
+}
+
+// Duration returns the delay after the given number of consecutive failed attempts, starting from 1.
+// The delay is randomly chosen between the half and the whole of the exponential delay.
+func (b Backoff) Duration(failures int) time.Duration {
+	if failures <= 0 || b.Min <= 0 {
+		return 0
+	}
+
+	d := b.Min
+	for i := 1; i < failures && d < b.Max; i++ {
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+
+	half := int64(d / 2)
+
+	return time.Duration(half + rand.Int63n(half+1))
+}
@@ -0,0 +1,29 @@
+package backoff_test
+
+import (
+	"exporter/exporter/backoff"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Exponential backoff", func() {
+	b := backoff.Backoff{Min: 100 * time.Millisecond, Max: time.Second}
+
+	It("Doesn't delay before the first failure", func() {
+		Expect(b.Duration(0)).To(BeZero())
+	})
+
+	It("Doubles the delay with jitter after every failure", func() {
+		Expect(b.Duration(1)).To(BeNumerically("~", 75*time.Millisecond, 25*time.Millisecond))
+		Expect(b.Duration(3)).To(BeNumerically("~", 300*time.Millisecond, 100*time.Millisecond))
+	})
+
+	It("Limits the delay with the maximum", func() {
+		Expect(b.Duration(100)).To(BeNumerically("~", 750*time.Millisecond, 250*time.Millisecond))
+	})
+
+	It("Is disabled without the minimum delay", func() {
+		Expect(backoff.Backoff{}.Duration(5)).To(BeZero())
+	})
+})
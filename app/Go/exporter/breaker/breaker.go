@@ -0,0 +1,119 @@
+package breaker
+
+import (
+	"errors"
+	"exporter/exporter/backoff"
+	"sync"
+	"time"
+)
+
+// ErrOpen is returned instead of calling a target while the breaker is open.
+var ErrOpen = errors.New("circuit breaker is open")
+
+// State of the circuit breaker.
+type State int
+
+const (
+	// Closed breaker lets all calls through.
+	Closed State = iota
+	// Open breaker rejects all calls until the open period passes.
+	Open
+	// HalfOpen breaker lets a single probe call through to check whether the target recovered.
+	HalfOpen
+)
+
+// States lists all breaker states, e.g. to expose every state in a metric.
+var States = []State{Closed, Open, HalfOpen}
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker stops calls to a failing target after a number of consecutive failures.
+// The open period grows with the backoff every time the probe call fails.
+type Breaker struct {
+	threshold int
+	backoff   backoff.Backoff
+
+	mu          sync.Mutex
+	state       State
+	failures    int
+	opens       int
+	openedUntil time.Time
+	probing     bool
+}
+
+// New allocates a closed breaker which opens after threshold consecutive failures, threshold must be positive.
+func New(threshold int, openBackoff backoff.Backoff) *Breaker {
+	return &Breaker{
+		threshold: threshold,
+		backoff:   openBackoff,
+	}
+}
+
+// Allow returns ErrOpen if the call must not be made.
+// When the open period passes, the breaker becomes half-open and allows a single probe call.
+// Every allowed call must be followed by Success or Failure.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if time.Now().Before(b.openedUntil) {
+			return ErrOpen
+		}
+		b.state = HalfOpen
+		b.probing = true
+		return nil
+	case HalfOpen:
+		if b.probing {
+			return ErrOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// Success closes the breaker and resets the failure count.
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = Closed
+	b.failures = 0
+	b.opens = 0
+	b.probing = false
+}
+
+// Failure counts the failed call, the breaker opens when the threshold is reached or the probe call failed.
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+
+	if b.state == HalfOpen || b.failures >= b.threshold {
+		b.opens++
+		b.state = Open
+		b.openedUntil = time.Now().Add(b.backoff.Duration(b.opens))
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
@@ -0,0 +1,60 @@
+package breaker_test
+
+import (
+	"exporter/exporter/backoff"
+	"exporter/exporter/breaker"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Circuit breaker", func() {
+	var b *breaker.Breaker
+
+	BeforeEach(func() {
+		b = breaker.New(2, backoff.Backoff{Min: 50 * time.Millisecond, Max: time.Second})
+	})
+
+	It("Stays closed until the failure threshold is reached", func() {
+		Expect(b.Allow()).To(Succeed())
+		b.Failure()
+
+		Expect(b.State()).To(Equal(breaker.Closed))
+		Expect(b.Allow()).To(Succeed())
+	})
+
+	When("Failure threshold is reached", func() {
+		BeforeEach(func() {
+			b.Failure()
+			b.Failure()
+		})
+
+		It("Rejects calls while open", func() {
+			Expect(b.State()).To(Equal(breaker.Open))
+			Expect(b.Allow()).To(MatchError(breaker.ErrOpen))
+		})
+
+		It("Allows a single probe call after the open period", func() {
+			Eventually(b.Allow, time.Second, 10*time.Millisecond).Should(Succeed())
+
+			Expect(b.State()).To(Equal(breaker.HalfOpen))
+			Expect(b.Allow()).To(MatchError(breaker.ErrOpen))
+		})
+
+		It("Closes when the probe call succeeds", func() {
+			Eventually(b.Allow, time.Second, 10*time.Millisecond).Should(Succeed())
+			b.Success()
+
+			Expect(b.State()).To(Equal(breaker.Closed))
+			Expect(b.Allow()).To(Succeed())
+		})
+
+		It("Opens again when the probe call fails", func() {
+			Eventually(b.Allow, time.Second, 10*time.Millisecond).Should(Succeed())
+			b.Failure()
+
+			Expect(b.State()).To(Equal(breaker.Open))
+			Expect(b.Allow()).To(MatchError(breaker.ErrOpen))
+		})
+	})
+})
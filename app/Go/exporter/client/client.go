@@ -4,16 +4,55 @@ package client
 import (
 	"context"
 	"github.com/go-redis/redis/v8"
-	"time"
 )
 
-// SliceOfClients is used to pass around slice of clients.
-type SliceOfClients struct {
-	RedisClients []RedisClient
-}
-
 // RedisClient interface to mock the network requests to Redis.
+// Commands are sent to the default database of the connection, per-database commands are sent with WithDatabase.
 type RedisClient interface {
+	Do(ctx context.Context, args ...interface{}) *redis.Cmd
 	Info(ctx context.Context, section ...string) *redis.StringCmd
-	Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd
+	ConfigGet(ctx context.Context, parameter string) *redis.SliceCmd
+	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
+	Ping(ctx context.Context) *redis.StatusCmd
+	// Pipelined queues commands added by fn and sends them to Redis in a single round trip.
+	// Results of queued commands are returned in the order they were added.
+	Pipelined(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error)
+	// WithDatabase runs fn with commands sent to the database over a dedicated connection from the pool.
+	WithDatabase(ctx context.Context, database int, fn func(redis.Cmdable) error) error
+}
+
+var _ RedisClient = (*Client)(nil)
+
+// Client is a pooled client of a single Redis target, all databases of the target share its connections.
+type Client struct {
+	*redis.Client
+}
+
+// NewClient allocates a new client with the options, the database from options is used as the default one.
+func NewClient(options *redis.Options) *Client {
+	return &Client{
+		Client: redis.NewClient(options),
+	}
+}
+
+// WithDatabase runs fn with commands sent to the database over a dedicated connection from the pool.
+// The default database is selected back before the connection is returned to the pool.
+func (c *Client) WithDatabase(ctx context.Context, database int, fn func(redis.Cmdable) error) error {
+	conn := c.Conn(ctx)
+	defer conn.Close()
+
+	err := conn.Select(ctx, database).Err()
+	if err != nil {
+		return err
+	}
+
+	err = fn(conn)
+
+	// Connection with the failed SELECT is dropped by the pool, so it's never reused with the wrong database.
+	resetErr := conn.Select(ctx, c.Options().DB).Err()
+	if err != nil {
+		return err
+	}
+
+	return resetErr
 }
@@ -0,0 +1,158 @@
+package client_test
+
+import (
+	"bufio"
+	"context"
+	"exporter/exporter/client"
+	"exporter/exporter/tracing"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/sdk/export/trace/tracetest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var _ = Describe("Redis client", func() {
+	var (
+		listener    net.Listener
+		redisClient *client.Client
+		mu          sync.Mutex
+		commands    []string
+	)
+
+	// Returns commands received by the stub server so far.
+	received := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string{}, commands...)
+	}
+
+	BeforeEach(func() {
+		var err error
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+
+		commands = nil
+
+		// Stub server records commands and replies OK to each of them.
+		go func() {
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+
+				go serveStub(conn, func(command string) {
+					mu.Lock()
+					defer mu.Unlock()
+
+					commands = append(commands, command)
+				})
+			}
+		}()
+
+		redisClient = client.NewClient(&redis.Options{Addr: listener.Addr().String()})
+	})
+
+	AfterEach(func() {
+		redisClient.Close()
+		listener.Close()
+	})
+
+	It("Sends commands to the database and selects the default one back", func() {
+		ctx := context.Background()
+
+		err := redisClient.WithDatabase(ctx, 3, func(c redis.Cmdable) error {
+			return c.Set(ctx, "key", "value", 0).Err()
+		})
+
+		Expect(err).To(BeNil())
+		Expect(received()).To(Equal([]string{"select 3", "set key value", "select 0"}))
+	})
+
+	It("Exposes command and pool metrics", func() {
+		ctx := context.Background()
+		commandMetrics := client.NewCommandMetrics()
+		redisClient.AddHook(commandMetrics.Hook(listener.Addr().String()))
+
+		r := prometheus.NewRegistry()
+		r.MustRegister(commandMetrics, client.NewPoolCollector(func() *client.Client { return redisClient }))
+
+		Expect(redisClient.Ping(ctx).Err()).To(Succeed())
+
+		rr := httptest.NewRecorder()
+		promhttp.HandlerFor(r, promhttp.HandlerOpts{}).ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+		Expect(rr.Body.String()).To(ContainSubstring(fmt.Sprintf(`redis_exporter_redis_commands_total{cmd="ping",target="%s"} 1`, listener.Addr())))
+		Expect(rr.Body.String()).To(ContainSubstring(fmt.Sprintf(`redis_exporter_redis_command_duration_seconds_count{cmd="ping",target="%s"} 1`, listener.Addr())))
+		Expect(rr.Body.String()).To(ContainSubstring(fmt.Sprintf(`redis_exporter_pool_connections{target="%s"} 1`, listener.Addr())))
+	})
+
+	It("Traces commands under the span of the caller", func() {
+		exporter := tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(
+			sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+			sdktrace.WithSyncer(exporter),
+		))
+		defer otel.SetTracerProvider(trace.NewNoopTracerProvider())
+
+		redisClient.AddHook(client.CommandTracing{})
+
+		ctx, span := tracing.Start(context.Background(), "scrape")
+		Expect(redisClient.Ping(ctx).Err()).To(Succeed())
+		tracing.End(span, nil)
+
+		names := map[string]trace.SpanID{}
+		parents := map[string]trace.SpanID{}
+		for _, data := range exporter.GetSpans() {
+			names[data.Name] = data.SpanContext.SpanID
+			parents[data.Name] = data.ParentSpanID
+		}
+
+		Expect(names).To(HaveKey("ping"))
+		Expect(parents["ping"]).To(Equal(names["scrape"]))
+	})
+})
+
+// Reads RESP commands from the connection and replies OK to each of them.
+func serveStub(conn net.Conn, record func(command string)) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		count, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		args := []string{}
+		for i := 0; i < count; i++ {
+			// Skip the length line of the bulk string.
+			_, err = reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			arg, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			args = append(args, strings.ToLower(strings.TrimSpace(arg)))
+		}
+
+		record(strings.Join(args, " "))
+		conn.Write([]byte("+OK\r\n"))
+	}
+}
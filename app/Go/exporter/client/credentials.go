@@ -0,0 +1,97 @@
+package client
+
+import (
+	"context"
+	"exporter/exporter/secret"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"gopkg.in/yaml.v2"
+	"strings"
+)
+
+// Credentials holds the ACL username and password used to authenticate to Redis.
+type Credentials struct {
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+}
+
+// CredentialsSource resolves credentials per Redis address.
+// Secret files are re-read when they change, so rotated passwords are used for new connections.
+type CredentialsSource struct {
+	username        string
+	password        string
+	passwordFile    *secret.File
+	credentialsFile *secret.File
+}
+
+// NewCredentialsSource allocates a new credentials source.
+// Per-address entries from credentialsFile take precedence over the password from passwordFile,
+// which takes precedence over the plain password. Empty file paths are ignored.
+func NewCredentialsSource(username string, password string, passwordFile string, credentialsFile string) *CredentialsSource {
+	source := &CredentialsSource{
+		username: username,
+		password: password,
+	}
+
+	if passwordFile != "" {
+		source.passwordFile = secret.NewFile(passwordFile)
+	}
+
+	if credentialsFile != "" {
+		source.credentialsFile = secret.NewFile(credentialsFile)
+	}
+
+	return source
+}
+
+// Get returns credentials to be used for the Redis address.
+func (source *CredentialsSource) Get(address string) (Credentials, error) {
+	if source.credentialsFile != nil {
+		data, err := source.credentialsFile.Read()
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		credentials := make(map[string]Credentials)
+		err = yaml.Unmarshal(data, &credentials)
+		if err != nil {
+			return Credentials{}, fmt.Errorf("failed to parse credentials file %s: %v", source.credentialsFile.Path(), err)
+		}
+
+		if c, ok := credentials[address]; ok {
+			return c, nil
+		}
+	}
+
+	if source.passwordFile != nil {
+		data, err := source.passwordFile.Read()
+		if err != nil {
+			return Credentials{}, err
+		}
+
+		// Secret files usually end with a new line which is not a part of the password.
+		return Credentials{Username: source.username, Password: strings.TrimRight(string(data), "\r\n")}, nil
+	}
+
+	return Credentials{Username: source.username, Password: source.password}, nil
+}
+
+// OnConnect returns a hook which authenticates every new connection with current credentials.
+func (source *CredentialsSource) OnConnect(address string) func(ctx context.Context, cn *redis.Conn) error {
+	return func(ctx context.Context, cn *redis.Conn) error {
+		credentials, err := source.Get(address)
+		if err != nil {
+			return err
+		}
+
+		if credentials.Password == "" {
+			return nil
+		}
+
+		if credentials.Username != "" {
+			return cn.AuthACL(ctx, credentials.Username, credentials.Password).Err()
+		}
+
+		return cn.Auth(ctx, credentials.Password).Err()
+	}
+}
@@ -0,0 +1,108 @@
+package client_test
+
+import (
+	"exporter/exporter/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Redis credentials source", func() {
+	var (
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "credentials")
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	When("Only plain credentials are configured", func() {
+		It("Returns configured username and password", func() {
+			source := client.NewCredentialsSource("exporter", "secret", "", "")
+
+			credentials, err := source.Get("redis:6379")
+
+			Expect(err).To(BeNil())
+			Expect(credentials).To(Equal(client.Credentials{Username: "exporter", Password: "secret"}))
+		})
+	})
+
+	When("Password file is configured", func() {
+		var passwordFile string
+
+		BeforeEach(func() {
+			passwordFile = filepath.Join(dir, "password")
+			Expect(ioutil.WriteFile(passwordFile, []byte("first\n"), 0600)).To(Succeed())
+		})
+
+		It("Returns password from the file without trailing new line", func() {
+			source := client.NewCredentialsSource("exporter", "", passwordFile, "")
+
+			credentials, err := source.Get("redis:6379")
+
+			Expect(err).To(BeNil())
+			Expect(credentials).To(Equal(client.Credentials{Username: "exporter", Password: "first"}))
+		})
+
+		It("Re-reads the password after the file is changed", func() {
+			source := client.NewCredentialsSource("", "", passwordFile, "")
+
+			credentials, err := source.Get("redis:6379")
+			Expect(err).To(BeNil())
+			Expect(credentials.Password).To(Equal("first"))
+
+			Expect(ioutil.WriteFile(passwordFile, []byte("rotated\n"), 0600)).To(Succeed())
+			later := time.Now().Add(time.Minute)
+			Expect(os.Chtimes(passwordFile, later, later)).To(Succeed())
+
+			credentials, err = source.Get("redis:6379")
+			Expect(err).To(BeNil())
+			Expect(credentials.Password).To(Equal("rotated"))
+		})
+
+		It("Returns an error when the file is missing", func() {
+			source := client.NewCredentialsSource("", "", filepath.Join(dir, "missing"), "")
+
+			_, err := source.Get("redis:6379")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("Credentials file is configured", func() {
+		var credentialsFile string
+
+		BeforeEach(func() {
+			credentialsFile = filepath.Join(dir, "credentials.yaml")
+			data := "\"redis:6379\":\n  username: first\n  password: one\n\"redis:6380\":\n  username: second\n  password: two\n"
+			Expect(ioutil.WriteFile(credentialsFile, []byte(data), 0600)).To(Succeed())
+		})
+
+		It("Returns credentials per Redis address", func() {
+			source := client.NewCredentialsSource("default", "fallback", "", credentialsFile)
+
+			credentials, err := source.Get("redis:6380")
+
+			Expect(err).To(BeNil())
+			Expect(credentials).To(Equal(client.Credentials{Username: "second", Password: "two"}))
+		})
+
+		It("Falls back to other credentials for unknown addresses", func() {
+			source := client.NewCredentialsSource("default", "fallback", "", credentialsFile)
+
+			credentials, err := source.Get("redis:6381")
+
+			Expect(err).To(BeNil())
+			Expect(credentials).To(Equal(client.Credentials{Username: "default", Password: "fallback"}))
+		})
+	})
+})
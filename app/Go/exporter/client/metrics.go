@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+// Context key of the time the command or the pipeline was started at.
+type startKey struct{}
+
+// CommandMetrics counts Redis commands sent by clients and measures their latency per Redis target.
+// It's added to clients as go-redis hook built by Hook, commands of a pipeline get the duration of the whole pipeline.
+type CommandMetrics struct {
+	commands *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// commandHook records commands of a client of a single Redis target.
+type commandHook struct {
+	metrics *CommandMetrics
+	target  string
+}
+
+var _ redis.Hook = commandHook{}
+
+// NewCommandMetrics allocates new command metrics.
+func NewCommandMetrics() *CommandMetrics {
+	return &CommandMetrics{
+		commands: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "redis_exporter",
+			Name:      "redis_commands_total",
+			Help:      "Number of Redis commands sent by the exporter.",
+		}, []string{"cmd", "target"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "redis_exporter",
+			Name:      "redis_command_errors_total",
+			Help:      "Number of Redis commands sent by the exporter which failed.",
+		}, []string{"cmd", "target"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "redis_exporter",
+			Name:      "redis_command_duration_seconds",
+			Help:      "Latency of Redis commands sent by the exporter.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"cmd", "target"}),
+	}
+}
+
+// Hook returns go-redis hook which records commands of a client of the Redis target.
+func (m *CommandMetrics) Hook(target string) redis.Hook {
+	return commandHook{metrics: m, target: target}
+}
+
+// BeforeProcess stores the start time of the command.
+func (h commandHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+// AfterProcess records the command.
+func (h commandHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.observe(ctx, cmd)
+	return nil
+}
+
+// BeforeProcessPipeline stores the start time of the pipeline.
+func (h commandHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+// AfterProcessPipeline records every command of the pipeline.
+func (h commandHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.observe(ctx, cmd)
+	}
+	return nil
+}
+
+func (h commandHook) observe(ctx context.Context, cmd redis.Cmder) {
+	name := cmd.Name()
+	h.metrics.commands.WithLabelValues(name, h.target).Inc()
+
+	// Missing key is a valid reply, not a failure.
+	err := cmd.Err()
+	if err != nil && err != redis.Nil {
+		h.metrics.errors.WithLabelValues(name, h.target).Inc()
+	}
+
+	start, ok := ctx.Value(startKey{}).(time.Time)
+	if ok {
+		h.metrics.duration.WithLabelValues(name, h.target).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Describe writes all command metric descriptors to the Prometheus desc channel.
+func (m *CommandMetrics) Describe(ch chan<- *prometheus.Desc) {
+	m.commands.Describe(ch)
+	m.errors.Describe(ch)
+	m.duration.Describe(ch)
+}
+
+// Collect returns the current state of command metrics.
+func (m *CommandMetrics) Collect(ch chan<- prometheus.Metric) {
+	m.commands.Collect(ch)
+	m.errors.Collect(ch)
+	m.duration.Collect(ch)
+}
@@ -9,7 +9,6 @@ import (
 	redis "github.com/go-redis/redis/v8"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
-	time "time"
 )
 
 // MockRedisClient is a mock of RedisClient interface
@@ -35,6 +34,39 @@ func (m *MockRedisClient) EXPECT() *MockRedisClientMockRecorder {
 	return m.recorder
 }
 
+// ConfigGet mocks base method
+func (m *MockRedisClient) ConfigGet(arg0 context.Context, arg1 string) *redis.SliceCmd {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConfigGet", arg0, arg1)
+	ret0, _ := ret[0].(*redis.SliceCmd)
+	return ret0
+}
+
+// ConfigGet indicates an expected call of ConfigGet
+func (mr *MockRedisClientMockRecorder) ConfigGet(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConfigGet", reflect.TypeOf((*MockRedisClient)(nil).ConfigGet), arg0, arg1)
+}
+
+// Do mocks base method
+func (m *MockRedisClient) Do(arg0 context.Context, arg1 ...interface{}) *redis.Cmd {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{arg0}
+	for _, a := range arg1 {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Do", varargs...)
+	ret0, _ := ret[0].(*redis.Cmd)
+	return ret0
+}
+
+// Do indicates an expected call of Do
+func (mr *MockRedisClientMockRecorder) Do(arg0 interface{}, arg1 ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{arg0}, arg1...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Do", reflect.TypeOf((*MockRedisClient)(nil).Do), varargs...)
+}
+
 // Info mocks base method
 func (m *MockRedisClient) Info(arg0 context.Context, arg1 ...string) *redis.StringCmd {
 	m.ctrl.T.Helper()
@@ -54,16 +86,59 @@ func (mr *MockRedisClientMockRecorder) Info(arg0 interface{}, arg1 ...interface{
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockRedisClient)(nil).Info), varargs...)
 }
 
-// Set mocks base method
-func (m *MockRedisClient) Set(arg0 context.Context, arg1 string, arg2 interface{}, arg3 time.Duration) *redis.StatusCmd {
+// Ping mocks base method
+func (m *MockRedisClient) Ping(arg0 context.Context) *redis.StatusCmd {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Set", arg0, arg1, arg2, arg3)
+	ret := m.ctrl.Call(m, "Ping", arg0)
 	ret0, _ := ret[0].(*redis.StatusCmd)
 	return ret0
 }
 
-// Set indicates an expected call of Set
-func (mr *MockRedisClientMockRecorder) Set(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+// Ping indicates an expected call of Ping
+func (mr *MockRedisClientMockRecorder) Ping(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRedisClient)(nil).Ping), arg0)
+}
+
+// Pipelined mocks base method
+func (m *MockRedisClient) Pipelined(arg0 context.Context, arg1 func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pipelined", arg0, arg1)
+	ret0, _ := ret[0].([]redis.Cmder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pipelined indicates an expected call of Pipelined
+func (mr *MockRedisClientMockRecorder) Pipelined(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pipelined", reflect.TypeOf((*MockRedisClient)(nil).Pipelined), arg0, arg1)
+}
+
+// Scan mocks base method
+func (m *MockRedisClient) Scan(arg0 context.Context, arg1 uint64, arg2 string, arg3 int64) *redis.ScanCmd {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*redis.ScanCmd)
+	return ret0
+}
+
+// Scan indicates an expected call of Scan
+func (mr *MockRedisClientMockRecorder) Scan(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockRedisClient)(nil).Scan), arg0, arg1, arg2, arg3)
+}
+
+// WithDatabase mocks base method
+func (m *MockRedisClient) WithDatabase(arg0 context.Context, arg1 int, arg2 func(redis.Cmdable) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithDatabase", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithDatabase indicates an expected call of WithDatabase
+func (mr *MockRedisClientMockRecorder) WithDatabase(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockRedisClient)(nil).Set), arg0, arg1, arg2, arg3)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithDatabase", reflect.TypeOf((*MockRedisClient)(nil).WithDatabase), arg0, arg1, arg2)
 }
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	poolHitsTotal = prometheus.NewDesc(
+		"redis_exporter_pool_hits_total",
+		"Number of times a free connection was found in the pool.",
+		[]string{"target"}, nil,
+	)
+	poolMissesTotal = prometheus.NewDesc(
+		"redis_exporter_pool_misses_total",
+		"Number of times a free connection was not found in the pool.",
+		[]string{"target"}, nil,
+	)
+	poolTimeoutsTotal = prometheus.NewDesc(
+		"redis_exporter_pool_timeouts_total",
+		"Number of times waiting for a connection from the pool timed out.",
+		[]string{"target"}, nil,
+	)
+	poolConnections = prometheus.NewDesc(
+		"redis_exporter_pool_connections",
+		"Number of connections in the pool.",
+		[]string{"target"}, nil,
+	)
+	poolIdleConnections = prometheus.NewDesc(
+		"redis_exporter_pool_idle_connections",
+		"Number of idle connections in the pool.",
+		[]string{"target"}, nil,
+	)
+	poolStaleConnectionsTotal = prometheus.NewDesc(
+		"redis_exporter_pool_stale_connections_total",
+		"Number of stale connections removed from the pool.",
+		[]string{"target"}, nil,
+	)
+)
+
+// PoolCollector exposes connection pool statistics of the current client.
+type PoolCollector struct {
+	client func() *Client
+}
+
+// NewPoolCollector allocates a new collector of the client returned by the function.
+// Client is requested on every scrape, so statistics of the currently loaded configuration are exposed.
+func NewPoolCollector(client func() *Client) *PoolCollector {
+	return &PoolCollector{client: client}
+}
+
+// Describe writes all pool metric descriptors to the Prometheus desc channel.
+func (collector *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- poolHitsTotal
+	ch <- poolMissesTotal
+	ch <- poolTimeoutsTotal
+	ch <- poolConnections
+	ch <- poolIdleConnections
+	ch <- poolStaleConnectionsTotal
+}
+
+// Collect returns pool statistics, nothing is returned when there is no client.
+func (collector *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	c := collector.client()
+	if c == nil {
+		return
+	}
+
+	stats := c.PoolStats()
+	target := c.Options().Addr
+
+	ch <- prometheus.MustNewConstMetric(poolHitsTotal, prometheus.CounterValue, float64(stats.Hits), target)
+	ch <- prometheus.MustNewConstMetric(poolMissesTotal, prometheus.CounterValue, float64(stats.Misses), target)
+	ch <- prometheus.MustNewConstMetric(poolTimeoutsTotal, prometheus.CounterValue, float64(stats.Timeouts), target)
+	ch <- prometheus.MustNewConstMetric(poolConnections, prometheus.GaugeValue, float64(stats.TotalConns), target)
+	ch <- prometheus.MustNewConstMetric(poolIdleConnections, prometheus.GaugeValue, float64(stats.IdleConns), target)
+	ch <- prometheus.MustNewConstMetric(poolStaleConnectionsTotal, prometheus.CounterValue, float64(stats.StaleConns), target)
+}
@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"exporter/exporter/backoff"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Dialer establishes new connections to Redis, it has the signature of go-redis dialer.
+type Dialer func(ctx context.Context, network string, addr string) (net.Conn, error)
+
+// NetDialer returns a dialer of plain connections with the same settings as go-redis default dialer.
+func NetDialer(timeout time.Duration) Dialer {
+	netDialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 5 * time.Minute,
+	}
+
+	return netDialer.DialContext
+}
+
+// reconnectState tracks consecutive failed dials of a client.
+type reconnectState struct {
+	mu       sync.Mutex
+	failures int
+	retryAt  time.Time
+	err      error
+}
+
+// ReconnectBackoff wraps the dialer, so new connections are not attempted until the backoff delay after
+// a failed dial passes. Dials during the delay fail immediately with the last error instead of waiting for the timeout.
+func ReconnectBackoff(dial Dialer, b backoff.Backoff) Dialer {
+	state := &reconnectState{}
+
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		state.mu.Lock()
+		if state.err != nil && time.Now().Before(state.retryAt) {
+			err := state.err
+			state.mu.Unlock()
+			return nil, fmt.Errorf("waiting to reconnect to %s: %v", addr, err)
+		}
+		state.mu.Unlock()
+
+		conn, err := dial(ctx, network, addr)
+
+		state.mu.Lock()
+		defer state.mu.Unlock()
+
+		if err != nil {
+			state.failures++
+			state.retryAt = time.Now().Add(b.Duration(state.failures))
+			state.err = err
+			return nil, err
+		}
+
+		state.failures = 0
+		state.err = nil
+
+		return conn, nil
+	}
+}
@@ -0,0 +1,59 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"exporter/exporter/backoff"
+	"exporter/exporter/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net"
+	"time"
+)
+
+var _ = Describe("Reconnect backoff", func() {
+	var (
+		dials   int
+		failing bool
+		dialer  client.Dialer
+	)
+
+	BeforeEach(func() {
+		dials = 0
+		failing = true
+
+		// Stub dialer fails while the target is down.
+		dialer = client.ReconnectBackoff(func(ctx context.Context, network string, addr string) (net.Conn, error) {
+			dials++
+			if failing {
+				return nil, errors.New("connection refused")
+			}
+
+			server, conn := net.Pipe()
+			server.Close()
+			return conn, nil
+		}, backoff.Backoff{Min: 100 * time.Millisecond, Max: time.Second})
+	})
+
+	It("Fails immediately during the delay after a failed dial", func() {
+		_, err := dialer(context.Background(), "tcp", "redis:6379")
+		Expect(err).To(MatchError("connection refused"))
+
+		_, err = dialer(context.Background(), "tcp", "redis:6379")
+		Expect(err).To(MatchError(ContainSubstring("waiting to reconnect to redis:6379")))
+		Expect(dials).To(Equal(1))
+	})
+
+	It("Dials again after the delay", func() {
+		_, err := dialer(context.Background(), "tcp", "redis:6379")
+		Expect(err).To(HaveOccurred())
+
+		failing = false
+		time.Sleep(100 * time.Millisecond)
+
+		conn, err := dialer(context.Background(), "tcp", "redis:6379")
+		Expect(err).To(BeNil())
+		Expect(dials).To(Equal(2))
+		conn.Close()
+	})
+})
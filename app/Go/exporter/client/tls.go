@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"exporter/exporter/secret"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// TLSSource builds TLS configuration for connections to Redis.
+// Certificate files are re-read when they change, so rotated certificates are used for new connections.
+type TLSSource struct {
+	caFile     *secret.File
+	certFile   *secret.File
+	keyFile    *secret.File
+	serverName string
+	minVersion uint16
+
+	mu     sync.Mutex
+	config *tls.Config
+	// Content of the files the cached config was built from.
+	ca   []byte
+	cert []byte
+	key  []byte
+}
+
+// NewTLSSource allocates a new TLS source.
+// System CA pool is used when caFile is empty, client certificate is used only when both certFile and keyFile are set.
+func NewTLSSource(caFile string, certFile string, keyFile string, serverName string, minVersion uint16) (*TLSSource, error) {
+	if (certFile == "") != (keyFile == "") {
+		return nil, errors.New("both client certificate and key files must be set")
+	}
+
+	source := &TLSSource{
+		serverName: serverName,
+		minVersion: minVersion,
+	}
+
+	if caFile != "" {
+		source.caFile = secret.NewFile(caFile)
+	}
+
+	if certFile != "" {
+		source.certFile = secret.NewFile(certFile)
+		source.keyFile = secret.NewFile(keyFile)
+	}
+
+	return source, nil
+}
+
+// Config returns TLS configuration built from the current content of certificate files.
+func (source *TLSSource) Config() (*tls.Config, error) {
+	ca, err := secret.ReadOptional(source.caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := secret.ReadOptional(source.certFile)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := secret.ReadOptional(source.keyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	if source.config != nil && string(ca) == string(source.ca) && string(cert) == string(source.cert) && string(key) == string(source.key) {
+		return source.config, nil
+	}
+
+	config := &tls.Config{
+		ServerName: source.serverName,
+		MinVersion: source.minVersion,
+	}
+
+	if ca != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", source.caFile.Path())
+		}
+		config.RootCAs = pool
+	}
+
+	if cert != nil {
+		certificate, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		config.Certificates = []tls.Certificate{certificate}
+	}
+
+	source.config = config
+	source.ca = ca
+	source.cert = cert
+	source.key = key
+
+	return config, nil
+}
+
+// Dialer returns a function to be used as go-redis dialer, every new connection gets the current TLS configuration.
+func (source *TLSSource) Dialer(timeout time.Duration) Dialer {
+	return func(ctx context.Context, network string, addr string) (net.Conn, error) {
+		config, err := source.Config()
+		if err != nil {
+			return nil, err
+		}
+
+		// Verify the certificate against the host name of the address unless the server name is overridden.
+		if config.ServerName == "" {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				return nil, err
+			}
+			config = config.Clone()
+			config.ServerName = host
+		}
+
+		netDialer := &net.Dialer{
+			Timeout:   timeout,
+			KeepAlive: 5 * time.Minute,
+		}
+
+		// The context bounds both the TCP connection and the TLS handshake.
+		tlsDialer := &tls.Dialer{NetDialer: netDialer, Config: config}
+
+		return tlsDialer.DialContext(ctx, network, addr)
+	}
+}
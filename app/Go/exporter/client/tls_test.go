@@ -0,0 +1,164 @@
+package client_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"exporter/exporter/client"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Redis TLS source", func() {
+	var (
+		dir      string
+		caFile   string
+		certFile string
+		keyFile  string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "tls")
+		Expect(err).To(BeNil())
+
+		caFile = filepath.Join(dir, "ca.crt")
+		certFile = filepath.Join(dir, "client.crt")
+		keyFile = filepath.Join(dir, "client.key")
+
+		writeCertificate(certFile, keyFile, "first")
+		writeCertificate(caFile, filepath.Join(dir, "ca.key"), "localhost")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Rejects client certificate without a key", func() {
+		_, err := client.NewTLSSource("", certFile, "", "", 0)
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Builds configuration with CA pool, client certificate and settings", func() {
+		source, err := client.NewTLSSource(caFile, certFile, keyFile, "redis.internal", tls.VersionTLS12)
+		Expect(err).To(BeNil())
+
+		config, err := source.Config()
+
+		Expect(err).To(BeNil())
+		Expect(config.RootCAs).NotTo(BeNil())
+		Expect(config.Certificates).To(HaveLen(1))
+		Expect(config.ServerName).To(Equal("redis.internal"))
+		Expect(config.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+	})
+
+	It("Reloads the client certificate after the files are changed", func() {
+		source, err := client.NewTLSSource("", certFile, keyFile, "", 0)
+		Expect(err).To(BeNil())
+
+		first, err := source.Config()
+		Expect(err).To(BeNil())
+
+		writeCertificate(certFile, keyFile, "second")
+		later := time.Now().Add(time.Minute)
+		Expect(os.Chtimes(certFile, later, later)).To(Succeed())
+		Expect(os.Chtimes(keyFile, later, later)).To(Succeed())
+
+		second, err := source.Config()
+		Expect(err).To(BeNil())
+
+		Expect(second.Certificates[0].Certificate[0]).NotTo(Equal(first.Certificates[0].Certificate[0]))
+	})
+
+	It("Connects to TLS server verified with the CA file", func() {
+		serverCert, err := tls.LoadX509KeyPair(caFile, filepath.Join(dir, "ca.key"))
+		Expect(err).To(BeNil())
+
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+		Expect(err).To(BeNil())
+		defer listener.Close()
+
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+
+		source, err := client.NewTLSSource(caFile, "", "", "localhost", 0)
+		Expect(err).To(BeNil())
+
+		conn, err := source.Dialer(time.Second)(context.Background(), "tcp", listener.Addr().String())
+		Expect(err).To(BeNil())
+		conn.Close()
+	})
+
+	It("Stops the handshake when the context is cancelled", func() {
+		// The server accepts connections but never completes the handshake.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		defer listener.Close()
+
+		accepted := make(chan net.Conn, 1)
+		go func() {
+			conn, err := listener.Accept()
+			if err == nil {
+				accepted <- conn
+			}
+		}()
+
+		source, err := client.NewTLSSource(caFile, "", "", "localhost", 0)
+		Expect(err).To(BeNil())
+
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+
+		start := time.Now()
+		_, err = source.Dialer(time.Minute)(ctx, "tcp", listener.Addr().String())
+		Expect(err).To(HaveOccurred())
+		Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+
+		conn := <-accepted
+		conn.Close()
+	})
+})
+
+// Writes a self-signed certificate for the common name and its key.
+func writeCertificate(certFile string, keyFile string, commonName string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).To(BeNil())
+
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).To(Succeed())
+}
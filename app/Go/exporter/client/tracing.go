@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"exporter/exporter/tracing"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+	"strings"
+)
+
+// CommandTracing starts a span for every Redis command sent by clients.
+// It's added to clients as go-redis hook, a pipeline gets a single span with names of its commands.
+type CommandTracing struct{}
+
+var _ redis.Hook = CommandTracing{}
+
+// BeforeProcess starts the span of the command.
+func (CommandTracing) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	// Only the command name is recorded, arguments may contain credentials.
+	ctx, _ = tracing.Start(ctx, cmd.FullName(),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, semconv.DBOperationKey.String(cmd.Name())),
+	)
+
+	return ctx, nil
+}
+
+// AfterProcess ends the span of the command.
+func (CommandTracing) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	tracing.End(trace.SpanFromContext(ctx), commandError(cmd))
+	return nil
+}
+
+// BeforeProcessPipeline starts the span of the pipeline.
+func (CommandTracing) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.FullName()
+	}
+
+	ctx, _ = tracing.Start(ctx, "pipeline",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemRedis, label.String("db.redis.commands", strings.Join(names, " "))),
+	)
+
+	return ctx, nil
+}
+
+// AfterProcessPipeline ends the span of the pipeline, the first failed command is recorded.
+func (CommandTracing) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	var err error
+	for _, cmd := range cmds {
+		err = commandError(cmd)
+		if err != nil {
+			break
+		}
+	}
+
+	tracing.End(trace.SpanFromContext(ctx), err)
+	return nil
+}
+
+// Returns the error of the command, missing key is a valid reply and is not returned.
+func commandError(cmd redis.Cmder) error {
+	err := cmd.Err()
+	if err == redis.Nil {
+		return nil
+	}
+
+	return err
+}
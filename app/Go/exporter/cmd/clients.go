@@ -0,0 +1,136 @@
+package main
+
+import (
+	"exporter/exporter/backoff"
+	"exporter/exporter/client"
+	"exporter/exporter/config"
+	"github.com/go-redis/redis/v8"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Timeout for establishing new connections to Redis, same as go-redis default.
+const dialTimeout = 5 * time.Second
+
+// connectionKey holds the Redis connection details, clients with equal keys are interchangeable.
+// Content of secret files is not a part of the key as it's re-read by clients on every new connection.
+type connectionKey struct {
+	network         string
+	address         string
+	username        string
+	password        string
+	passwordFile    string
+	credentialsFile string
+	tls             config.TLSConfig
+	reconnect       config.BackoffConfig
+}
+
+// clientHooks returns hooks added to a new client of the Redis target, e.g. to instrument Redis commands.
+type clientHooks func(target string) []redis.Hook
+
+// redisConnection keeps the Redis client with its connection details.
+type redisConnection struct {
+	key    connectionKey
+	client *client.Client
+}
+
+// Creates a pooled client for the configured Redis target, databases are selected per command.
+// Client of the existing connection is reused if its connection details didn't change.
+// Hooks are added to the new client, e.g. to instrument Redis commands.
+func setupRedisClient(cfg *config.Config, existing *redisConnection, hooks clientHooks) (*redisConnection, error) {
+	endpoint, err := cfg.RedisEndpoint()
+	if err != nil {
+		return nil, err
+	}
+
+	// TLS settings are kept in the key only when TLS is used, so they don't affect plain connections.
+	tlsConfig := config.TLSConfig{}
+	if endpoint.TLS {
+		tlsConfig = cfg.RedisTLS
+		tlsConfig.Enabled = true
+	}
+
+	// Credentials from the Redis URL are used when they are not configured separately.
+	username := cfg.RedisUsername
+	if username == "" {
+		username = endpoint.Username
+	}
+	password := cfg.RedisPassword
+	if password == "" {
+		password = endpoint.Password
+	}
+
+	key := connectionKey{
+		network:         endpoint.Network,
+		address:         endpoint.Address,
+		username:        username,
+		password:        password,
+		passwordFile:    cfg.RedisPasswordFile,
+		credentialsFile: cfg.RedisCredentialsFile,
+		tls:             tlsConfig,
+		reconnect:       cfg.ReconnectBackoff,
+	}
+
+	if existing != nil && existing.key == key {
+		return existing, nil
+	}
+
+	c, err := newRedisClient(key, hooks)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisConnection{key: key, client: c}, nil
+}
+
+// Creates a new Redis client with the connection details.
+func newRedisClient(key connectionKey, hooks clientHooks) (*client.Client, error) {
+	credentials := client.NewCredentialsSource(key.username, key.password, key.passwordFile, key.credentialsFile)
+
+	// Authentication is done by the OnConnect hook to pick up rotated credentials.
+	options := &redis.Options{
+		Network:   key.network,
+		Addr:      key.address,
+		OnConnect: credentials.OnConnect(key.address),
+	}
+
+	dialer := client.NetDialer(dialTimeout)
+	if key.tls.Enabled {
+		minVersion, err := config.ParseTLSVersion(key.tls.MinVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsSource, err := client.NewTLSSource(key.tls.CAFile, key.tls.CertFile, key.tls.KeyFile, key.tls.ServerName, minVersion)
+		if err != nil {
+			return nil, err
+		}
+
+		// Custom dialer builds TLS configuration for every new connection to pick up rotated certificates.
+		dialer = tlsSource.Dialer(dialTimeout)
+	}
+
+	// Unavailable Redis is not dialed on every command, new connections are attempted with the backoff.
+	options.Dialer = client.ReconnectBackoff(dialer, backoff.Backoff{Min: key.reconnect.Min, Max: key.reconnect.Max})
+
+	c := client.NewClient(options)
+	if hooks != nil {
+		for _, hook := range hooks(key.address) {
+			c.AddHook(hook)
+		}
+	}
+
+	return c, nil
+}
+
+// Closes the client of the previous connection if it's not used by the current one.
+func closeUnusedClient(previous *redisConnection, current *redisConnection) {
+	if previous == nil || previous == current {
+		return
+	}
+
+	err := previous.client.Close()
+	if err != nil {
+		zap.S().Warnf("Failed to close Redis client for %s: %v", previous.key.address, err)
+	}
+}
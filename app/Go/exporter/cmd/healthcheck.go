@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/tls"
+	"exporter/exporter/config"
+	"exporter/exporter/health"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Timeout of the healthcheck request.
+const healthcheckTimeout = 5 * time.Second
+
+// Requests the health endpoint of the exporter running with the same configuration.
+// Returns exit code for the Docker HEALTHCHECK: 0 for healthy and 1 for unhealthy exporter.
+func runHealthcheck() int {
+	err := healthcheck()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "Healthcheck failed:", err)
+		return 1
+	}
+
+	return 0
+}
+
+func healthcheck() error {
+	cfg, err := config.Load(configPath, configName)
+	if err != nil {
+		return err
+	}
+
+	webConfig, err := loadWebConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Exporter listens on all interfaces when the host is not set.
+	host, port, err := net.SplitHostPort(cfg.ExporterPort)
+	if err != nil {
+		return err
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	address := net.JoinHostPort(host, port)
+
+	if webConfig == nil || webConfig.TLSServerConfig == nil {
+		return health.Check("http://"+address+"/healthz", nil, healthcheckTimeout)
+	}
+
+	certificate, err := tls.LoadX509KeyPair(webConfig.TLSServerConfig.CertFile, webConfig.TLSServerConfig.KeyFile)
+	if err != nil {
+		return err
+	}
+
+	// Servers which verify client certificates against a CA need a client certificate signed by it,
+	// the server certificate is presented when it's not configured.
+	clientCertificate := certificate
+	if cfg.HealthcheckTLS.CertFile != "" {
+		clientCertificate, err = tls.LoadX509KeyPair(cfg.HealthcheckTLS.CertFile, cfg.HealthcheckTLS.KeyFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	return health.Check("https://"+address+"/healthz", health.PinnedTLSConfig(certificate, &clientCertificate), healthcheckTimeout)
+}
@@ -2,153 +2,383 @@ package main
 
 import (
 	"context"
-	"crypto/tls"
+	"exporter/exporter/backoff"
+	"exporter/exporter/breaker"
 	"exporter/exporter/client"
 	"exporter/exporter/collector"
+	"exporter/exporter/config"
+	"exporter/exporter/health"
+	"exporter/exporter/logging"
+	"exporter/exporter/relabel"
+	"exporter/exporter/tracing"
+	"exporter/exporter/version"
+	"exporter/exporter/web"
+	"flag"
+	"fmt"
 	"github.com/go-redis/redis/v8"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
-	"github.com/spf13/viper"
 	"go.uber.org/zap"
-	"go.uber.org/zap/zapcore"
 	"net/http"
+	"os"
 	"strconv"
+	"time"
 )
 
-// config declares connection and parser details.
-type config struct {
-	ExporterPort string `mapstructure:"exporter_port"`
+// Location of the configuration file, it's re-read from the same place on reload.
+const (
+	configPath = "./exporter/cmd/config"
+	configName = "configuration"
+)
+
+// Readiness PING results are cached to not load Redis with frequent probes.
+const (
+	readinessCacheTTL = 5 * time.Second
+	readinessTimeout  = 2 * time.Second
+)
+
+// Time given to export buffered spans on shutdown.
+const tracingFlushTimeout = 5 * time.Second
+
+// Command line flags take precedence over log settings of the configuration file.
+var (
+	logLevel  = flag.String("log.level", "", "Log level: debug, info, warn or error.")
+	logFormat = flag.String("log.format", "", "Log format: console, json or logfmt.")
+	logOutput = flag.String("log.output", "", "Log destination: stdout, stderr or a file path.")
+)
+
+// Sub-collectors are enabled and disabled with --collector.<name> flags, e.g. --collector.slowlog=true.
+var collectorFlags = registerCollectorFlags()
 
-	RedisAddress  string `mapstructure:"redis_address"`
-	RedisPassword string `mapstructure:"redis_password"`
+// Registers a flag for every available sub-collector.
+func registerCollectorFlags() map[string]*bool {
+	flags := make(map[string]*bool)
+	for _, info := range collector.AvailableCollectors() {
+		flags[info.Name] = flag.Bool("collector."+info.Name, info.EnabledByDefault, "Enable the "+info.Name+" collector: "+info.Help)
+	}
 
-	RedisDatabases []int `mapstructure:"redis_databases"`
+	return flags
+}
 
-	RequiredMetrics []string `mapstructure:"required_metrics"`
+// Returns names of sub-collectors enabled by command line flags.
+func enabledCollectors() []string {
+	var names []string
+	for name, enabled := range collectorFlags {
+		if *enabled {
+			names = append(names, name)
+		}
+	}
+
+	return names
 }
 
-var cfg config
-var ctx = context.Background()
-
-// Initialize logger to replace the default one.
-func initLogger() error {
-	// Initialize the logs encoder.
-	encoder := zap.NewProductionEncoderConfig()
-	encoder.EncodeTime = zapcore.ISO8601TimeEncoder
-	encoder.EncodeDuration = zapcore.StringDurationEncoder
-
-	// Initialize the logger.
-	logger, err := zap.Config{
-		Level:            zap.NewAtomicLevelAt(zap.InfoLevel),
-		Encoding:         "console",
-		EncoderConfig:    encoder,
-		OutputPaths:      []string{"stderr"},
-		ErrorOutputPaths: []string{"stderr"},
-	}.Build()
+// Returns log settings of the configuration file overridden by command line flags.
+func logConfig(cfg config.LogConfig) config.LogConfig {
+	if *logLevel != "" {
+		cfg.Level = *logLevel
+	}
+	if *logFormat != "" {
+		cfg.Format = *logFormat
+	}
+	if *logOutput != "" {
+		cfg.Output = *logOutput
+	}
+
+	return cfg.WithDefaults()
+}
+
+// Initialize logger to replace the default one, its level can be changed at runtime with the returned level.
+func initLogger(cfg config.LogConfig) (zap.AtomicLevel, error) {
+	logger, level, err := logging.New(cfg)
 	if err != nil {
-		return err
+		return level, err
 	}
 
 	// Replace the default logger with zap logger.
 	zap.ReplaceGlobals(logger)
 
-	return nil
+	return level, nil
 }
 
-// Load all configuration details from local .yaml file.
-func loadConfiguration() error {
-	viper.AddConfigPath("./exporter/cmd/config")
-	viper.SetConfigName("configuration")
-
-	err := viper.ReadInConfig()
-	if err != nil {
-		return err
-	}
+// Writes data to all configured Redis databases on startup to make Redis create them.
+// If there are 5 databases configured, then create 5 and get all metrics.
+func setDefaultValuesOnStartup(ctx context.Context, redisClient client.RedisClient, databases []int) error {
+	for i, db := range databases {
+		index := strconv.Itoa(i)
+		err := redisClient.WithDatabase(ctx, db, func(c redis.Cmdable) error {
+			// Add more data to the first database to make difference in metrics noticeable.
+			if i == 0 {
+				err := c.Set(ctx, "test", "test", 0).Err()
+				if err != nil {
+					return err
+				}
+			}
 
-	err = viper.Unmarshal(&cfg)
-	if err != nil {
-		return err
+			return c.Set(ctx, "key"+index, "value"+index, 0).Err()
+		})
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func setupRedisClients() client.SliceOfClients {
-	clients := client.SliceOfClients{}
+// Retries writing default values with the backoff until it succeeds or the context is cancelled,
+// so the exporter starts serving metrics while Redis is unavailable.
+func seedDatabasesInBackground(ctx context.Context, redisClient client.RedisClient, databases []int, b backoff.Backoff) {
+	for failures := 1; ; failures++ {
+		err := setDefaultValuesOnStartup(ctx, redisClient, databases)
+		if err == nil {
+			zap.S().Info("Default values were set to Redis databases.")
+			return
+		}
 
-	for i, _ := range cfg.RedisDatabases {
-		client := redis.NewClient(&redis.Options{
-			Addr:     cfg.RedisAddress,
-			Password: cfg.RedisPassword,
-			DB:       cfg.RedisDatabases[i],
-		})
+		delay := b.Duration(failures)
+		zap.S().Warnf("Failed to set default values to Redis databases, retrying in %s: %v", delay, err)
 
-		clients.RedisClients = append(clients.RedisClients, *client)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
 	}
+}
 
-	return clients
+// Returns the circuit breaker of Redis target, nil is returned when the breaker is disabled.
+func newBreaker(cfg *config.Config) *breaker.Breaker {
+	if cfg.CircuitBreaker.FailureThreshold == 0 {
+		return nil
+	}
+
+	return breaker.New(cfg.CircuitBreaker.FailureThreshold, backoff.Backoff{
+		Min: cfg.CircuitBreaker.MinOpenDuration,
+		Max: cfg.CircuitBreaker.MaxOpenDuration,
+	})
 }
 
-// Writes data to all configured Redis databases on startup to make Redis create them.
-// If there are 5 databases configured, then create 5 and get all metrics.
-func setDefaultValuesOnStartup(clients client.SliceOfClients) error {
-	// Add more data to two first database to make difference in metrics noticeable.
-	err := clients.RedisClients[0].Set(ctx, "test", "test", 0).Err()
-	if err != nil {
-		return err
+// Loads the web configuration, nil is returned when web configuration file is not set.
+// Plain HTTP without authentication is served in this case.
+func loadWebConfig(cfg *config.Config) (*web.Config, error) {
+	if cfg.WebConfigFile == "" {
+		return nil, nil
 	}
 
-	for i, v := range clients.RedisClients {
-		index := strconv.Itoa(i)
-		err := v.Set(ctx, "key"+index, "value"+index, 0).Err()
-		if err != nil {
-			return err
+	return web.LoadConfig(cfg.WebConfigFile)
+}
+
+// Returns refresh intervals and timeouts of required sections for the collector.
+func sectionOptions(cfg *config.Config) map[string]collector.SectionOptions {
+	options := make(map[string]collector.SectionOptions)
+	for _, section := range cfg.RequiredMetrics {
+		options[section.Name] = collector.SectionOptions{
+			Interval: section.Interval,
+			Timeout:  section.Timeout,
 		}
 	}
 
-	return nil
+	return options
+}
+
+// Returns refresh intervals and timeouts of sub-collectors for the collector.
+func collectorOptions(cfg *config.Config) map[string]collector.SectionOptions {
+	options := make(map[string]collector.SectionOptions)
+	for _, c := range cfg.CollectorOptions {
+		options[c.Name] = collector.SectionOptions{
+			Interval: c.Interval,
+			Timeout:  c.Timeout,
+		}
+	}
+
+	return options
+}
+
+// Returns the concurrency, the default timeout and pipelining of section queries for the collector.
+func scrapeOptions(cfg *config.Config) collector.ScrapeOptions {
+	return collector.ScrapeOptions{
+		Workers:        cfg.ScrapeConcurrency,
+		SectionTimeout: cfg.SectionTimeout,
+		Pipeline:       cfg.ScrapePipeline,
+	}
 }
 
 func main() {
-	// Disable cert verification to use self-signed certificates for internal service needs.
-	http.DefaultTransport.(*http.Transport).TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	// Check the health of running exporter instead of starting a new one, used by Docker HEALTHCHECK.
+	if len(os.Args) > 1 && os.Args[1] == "healthcheck" {
+		os.Exit(runHealthcheck())
+	}
 
 	// Global logging synchronizer.
 	// This ensures the logged data is flushed out of the buffer before program exits.
-	defer zap.S().Sync()
+	// Logger is resolved when the program exits, so the one built by initLogger is synced.
+	defer func() {
+		zap.S().Sync()
+	}()
+
+	flag.Parse()
+
+	// Errors of loading the configuration are reported by a temporary logger writing to stderr,
+	// the logger of the exporter is built once from the loaded configuration.
+	bootstrapCfg := logConfig(config.LogConfig{})
+	bootstrapCfg.Output = "stderr"
+	bootstrap, _, err := logging.New(bootstrapCfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	zap.ReplaceGlobals(bootstrap)
+
+	cfg, err := config.Load(configPath, configName)
+	if err != nil {
+		zap.S().Fatal(err)
+	}
 
-	err := initLogger()
+	// Entries of the temporary logger are flushed before it's replaced, syncing stderr fails on some terminals.
+	_ = bootstrap.Sync()
+	level, err := initLogger(logConfig(cfg.Log))
 	if err != nil {
 		zap.S().Fatal(err)
 	}
 
-	err = loadConfiguration()
+	// Root context is cancelled on shutdown, so Redis calls don't outlive the exporter.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
 	if err != nil {
 		zap.S().Fatal(err)
 	}
 
-	clients := setupRedisClients()
+	// Redis commands are counted per target by the hook of every client when self metrics are enabled.
+	// Every Redis command gets a span under the span of the scrape when tracing is enabled.
+	commandMetrics := client.NewCommandMetrics()
+	hooks := func(target string) []redis.Hook {
+		hooks := []redis.Hook{}
+		if cfg.SelfMetrics {
+			hooks = append(hooks, commandMetrics.Hook(target))
+		}
+		if cfg.Tracing.Exporter != "" {
+			hooks = append(hooks, client.CommandTracing{})
+		}
+
+		return hooks
+	}
 
-	err = setDefaultValuesOnStartup(clients)
+	connection, err := setupRedisClient(cfg, nil, hooks)
 	if err != nil {
 		zap.S().Fatal(err)
 	}
 
-	zap.S().Info("Default values were set to both Redis databases.")
+	go seedDatabasesInBackground(ctx, connection.client, cfg.RedisDatabases, backoff.Backoff{
+		Min: cfg.ReconnectBackoff.Min,
+		Max: cfg.ReconnectBackoff.Max,
+	})
 
-	// Create a new instance of the collector and register it with the prometheus client.
-	collector := collector.NewMetricsCollector(ctx, clients, cfg.RequiredMetrics, cfg.RedisDatabases)
+	// Create a new instance of the collector, it's scraped with the context of every request.
+	metricsCollector := collector.NewMetricsCollector(ctx, connection.client, cfg.SectionNames(), cfg.RedisDatabases)
+	metricsCollector.SetTarget(connection.key.address)
+	metricsCollector.SetSectionOptions(sectionOptions(cfg))
+	metricsCollector.SetScrapeOptions(scrapeOptions(cfg))
+	metricsCollector.SetBreaker(newBreaker(cfg))
+
+	relabeler, err := relabel.New(cfg.MetricFilters, cfg.RelabelRules)
+	if err != nil {
+		zap.S().Fatal(err)
+	}
+	metricsCollector.SetRelabeler(relabeler)
+	metricsCollector.SetNamingScheme(collector.NamingScheme(cfg.NamingScheme))
+
+	err = metricsCollector.SetCollectors(enabledCollectors())
+	if err != nil {
+		zap.S().Fatal(err)
+	}
+
+	err = metricsCollector.SetCollectorOptions(collectorOptions(cfg))
+	if err != nil {
+		zap.S().Fatal(err)
+	}
+
+	err = metricsCollector.SetConstLabels(cfg.TargetLabels())
+	if err != nil {
+		zap.S().Fatal(err)
+	}
+
+	// Serve scrapes from the snapshot polled in background to not multiply the load on Redis.
+	if cfg.PollingInterval > 0 {
+		metricsCollector.StartPolling(ctx, cfg.PollingInterval)
+	}
 
 	// Get rid of any additional metrics, it should expose only required metrics with a custom registry.
 	r := prometheus.NewRegistry()
-	r.MustRegister(collector)
-	handler := promhttp.HandlerFor(r, promhttp.HandlerOpts{})
-	http.Handle("/metrics", handler)
+	handler := collector.NewHandler(metricsCollector, r)
+
+	webConfig, err := loadWebConfig(cfg)
+	if err != nil {
+		zap.S().Fatal(err)
+	}
+
+	// Protect endpoints with basic auth and bearer tokens according to the web configuration.
+	authenticator := web.NewAuthenticator(webConfig)
+	r.MustRegister(authenticator)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", handler)
+
+	// Reload the configuration on SIGHUP and on POST request to the reload endpoint.
+	// The endpoint is served only to loopback clients unless it requires credentials.
+	reloader := newReloader(cfg, connection, hooks, metricsCollector, level)
+	r.MustRegister(reloader)
+	go reloader.watchSignals()
+	mux.Handle("/-/reload", authenticator.LoopbackOnly("/-/reload", reloader))
+
+	// Return the current log level on GET and change it on PUT request with {"level": "debug"} body.
+	// The endpoint is served only to loopback clients unless it requires credentials.
+	mux.Handle("/-/log-level", authenticator.LoopbackOnly("/-/log-level", level))
+
+	// Tell whether slow scrapes are caused by Redis or by the exporter itself.
+	if cfg.SelfMetrics {
+		r.MustRegister(
+			version.NewCollector(),
+			prometheus.NewGoCollector(),
+			prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+			commandMetrics,
+			client.NewPoolCollector(reloader.pooledClient),
+		)
+		metricsCollector.EnableSelfMetrics()
+	}
+
+	// Liveness, readiness and landing page with the state of currently loaded configuration.
+	checker := health.NewChecker(reloader.currentClient, readinessCacheTTL, readinessTimeout)
+	mux.HandleFunc("/healthz", checker.Healthz)
+	mux.HandleFunc("/readyz", checker.Readyz)
+	mux.Handle("/", health.NewLandingPage(func() health.Status {
+		return reloader.status(metricsCollector.LastScrape())
+	}, checker))
+
+	server := &http.Server{
+		Addr:    cfg.ExporterPort,
+		Handler: tracing.Handler(authenticator.Handler(mux)),
+	}
+
+	// Drain in-flight scrapes on SIGTERM or SIGINT, then cancel Redis calls, close the client and flush traces.
+	stopped := shutdownOnSignal(server, func() {
+		cancel()
+		reloader.close()
+
+		flushCtx, cancelFlush := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancelFlush()
+
+		err := shutdownTracing(flushCtx)
+		if err != nil {
+			zap.S().Warnf("Failed to flush traces: %v", err)
+		}
+	})
 
 	zap.S().Infof("Starting the server on port %s", cfg.ExporterPort)
-	zap.S().Fatal(http.ListenAndServeTLS(
-		cfg.ExporterPort,
-		"./exporter/crt.crt",
-		"./exporter/key.key",
-		nil))
+	err = web.ListenAndServe(server, webConfig)
+	if err != http.ErrServerClosed {
+		zap.S().Fatal(err)
+	}
+
+	<-stopped
+	zap.S().Info("Exporter stopped.")
 }
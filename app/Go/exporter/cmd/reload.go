@@ -0,0 +1,268 @@
+package main
+
+import (
+	"exporter/exporter/client"
+	"exporter/exporter/collector"
+	"exporter/exporter/config"
+	"exporter/exporter/health"
+	"exporter/exporter/relabel"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// reloader re-reads the configuration file and applies it to the running exporter.
+type reloader struct {
+	mu         sync.Mutex
+	cfg        *config.Config
+	connection *redisConnection
+	hooks      clientHooks
+	collector  *collector.MetricsCollector
+	level      zap.AtomicLevel
+
+	// Location of the configuration file which is re-read, configPath and configName by default.
+	path string
+	name string
+
+	lastReloadSuccessful       prometheus.Gauge
+	lastReloadSuccessTimestamp prometheus.Gauge
+	configHash                 prometheus.Gauge
+}
+
+// newReloader allocates a new reloader for the configuration the exporter was started with.
+// Hooks are added to Redis clients created on reload, the log level is changed when it's reconfigured.
+func newReloader(cfg *config.Config, connection *redisConnection, hooks clientHooks, collector *collector.MetricsCollector, level zap.AtomicLevel) *reloader {
+	r := &reloader{
+		path:       configPath,
+		name:       configName,
+		cfg:        cfg,
+		connection: connection,
+		hooks:      hooks,
+		collector:  collector,
+		level:      level,
+		lastReloadSuccessful: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "redis_exporter",
+			Name:      "config_last_reload_successful",
+			Help:      "Whether the last configuration reload attempt was successful.",
+		}),
+		lastReloadSuccessTimestamp: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "redis_exporter",
+			Name:      "config_last_reload_success_timestamp_seconds",
+			Help:      "Timestamp of the last successful configuration reload.",
+		}),
+		configHash: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "redis_exporter",
+			Name:      "config_hash",
+			Help:      "Hash of the currently loaded configuration file.",
+		}),
+	}
+
+	// Initial load counts as a successful reload.
+	r.lastReloadSuccessful.Set(1)
+	r.lastReloadSuccessTimestamp.SetToCurrentTime()
+	r.configHash.Set(cfg.HashValue())
+
+	return r
+}
+
+// Reloads the configuration, the previous one is kept if the new one can't be loaded or is invalid.
+func (r *reloader) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := config.Load(r.path, r.name)
+	if err != nil {
+		r.lastReloadSuccessful.Set(0)
+		return err
+	}
+
+	if cfg.ExporterPort != r.cfg.ExporterPort {
+		zap.S().Warnf("Changing exporter_port from %s to %s requires a restart, keeping the current port.", r.cfg.ExporterPort, cfg.ExporterPort)
+	}
+
+	if cfg.WebConfigFile != r.cfg.WebConfigFile {
+		zap.S().Warnf("Changing web_config_file from %s to %s requires a restart, keeping the current web configuration.", r.cfg.WebConfigFile, cfg.WebConfigFile)
+	}
+
+	if cfg.PollingInterval != r.cfg.PollingInterval {
+		zap.S().Warnf("Changing polling_interval from %s to %s requires a restart, keeping the current interval.", r.cfg.PollingInterval, cfg.PollingInterval)
+	}
+
+	if cfg.SelfMetrics != r.cfg.SelfMetrics {
+		zap.S().Warnf("Changing self_metrics from %t to %t requires a restart, keeping the current metrics.", r.cfg.SelfMetrics, cfg.SelfMetrics)
+	}
+
+	if cfg.Tracing != r.cfg.Tracing {
+		zap.S().Warn("Changing tracing requires a restart, keeping the current tracing settings.")
+	}
+
+	// Only the level of the running logger can be changed.
+	logCfg, previousLogCfg := logConfig(cfg.Log), logConfig(r.cfg.Log)
+	if logCfg.Format != previousLogCfg.Format || logCfg.Output != previousLogCfg.Output || logCfg.Sampling != previousLogCfg.Sampling {
+		zap.S().Warn("Changing log format, output or sampling requires a restart, keeping the current logger.")
+	}
+
+	relabeler, err := relabel.New(cfg.MetricFilters, cfg.RelabelRules)
+	if err != nil {
+		r.lastReloadSuccessful.Set(0)
+		return err
+	}
+
+	labels := cfg.TargetLabels()
+	err = collector.ValidateConstLabels(labels)
+	if err != nil {
+		r.lastReloadSuccessful.Set(0)
+		return err
+	}
+
+	err = collector.ValidateCollectorOptions(collectorOptions(cfg))
+	if err != nil {
+		r.lastReloadSuccessful.Set(0)
+		return err
+	}
+
+	// Rebuild the client only if connection details changed and swap the collector settings.
+	connection, err := setupRedisClient(cfg, r.connection, r.hooks)
+	if err != nil {
+		r.lastReloadSuccessful.Set(0)
+		return err
+	}
+
+	// Level set through the log level endpoint is kept until the configured level changes.
+	if logCfg.Level != previousLogCfg.Level {
+		level, _ := logCfg.ParseLevel()
+		r.level.SetLevel(level)
+	}
+
+	r.collector.SetTarget(connection.key.address)
+	r.collector.SetSectionOptions(sectionOptions(cfg))
+	r.collector.SetScrapeOptions(scrapeOptions(cfg))
+	// Collector options were validated above.
+	_ = r.collector.SetCollectorOptions(collectorOptions(cfg))
+	r.collector.SetRelabeler(relabeler)
+	r.collector.SetNamingScheme(collector.NamingScheme(cfg.NamingScheme))
+	// Labels were validated above.
+	_ = r.collector.SetConstLabels(labels)
+	// Breaker state belongs to the target, so it's reset together with the client.
+	if connection != r.connection || cfg.CircuitBreaker != r.cfg.CircuitBreaker {
+		r.collector.SetBreaker(newBreaker(cfg))
+	}
+	scrapesDone := r.collector.UpdateSettings(connection.client, cfg.SectionNames(), cfg.RedisDatabases)
+	// Scrapes which are still running use the previous client, so it's closed after they finish.
+	go func(previous *redisConnection) {
+		<-scrapesDone
+		closeUnusedClient(previous, connection)
+	}(r.connection)
+
+	r.cfg = cfg
+	r.connection = connection
+
+	r.lastReloadSuccessful.Set(1)
+	r.lastReloadSuccessTimestamp.SetToCurrentTime()
+	r.configHash.Set(cfg.HashValue())
+
+	return nil
+}
+
+// Closes the Redis client on shutdown.
+func (r *reloader) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The client is not used anymore.
+	closeUnusedClient(r.connection, nil)
+	r.connection = nil
+}
+
+// Returns the client of the currently loaded configuration, nil is returned after shutdown.
+func (r *reloader) currentClient() client.RedisClient {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.connection == nil {
+		return nil
+	}
+
+	return r.connection.client
+}
+
+// Returns the pooled client of the currently loaded configuration, nil is returned after shutdown.
+func (r *reloader) pooledClient() *client.Client {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.connection == nil {
+		return nil
+	}
+
+	return r.connection.client
+}
+
+// Returns the exporter state for the currently loaded configuration.
+func (r *reloader) status(lastScrape collector.ScrapeStatus) health.Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Address is shown without the URL, which can contain credentials.
+	endpoint, _ := r.cfg.RedisEndpoint()
+
+	return health.Status{
+		Targets:    []string{endpoint.Address},
+		Sections:   r.cfg.SectionNames(),
+		Databases:  r.cfg.RedisDatabases,
+		LastScrape: lastScrape,
+	}
+}
+
+// Reloads the configuration every time the process receives SIGHUP.
+func (r *reloader) watchSignals() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+
+	for range hup {
+		r.logResult(r.reload())
+	}
+}
+
+// ServeHTTP reloads the configuration on POST request.
+func (r *reloader) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Only POST requests are allowed.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := r.reload()
+	r.logResult(err)
+	if err != nil {
+		http.Error(w, "Failed to reload configuration: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// Describe writes all reload metric descriptors to the Prometheus desc channel.
+func (r *reloader) Describe(ch chan<- *prometheus.Desc) {
+	r.lastReloadSuccessful.Describe(ch)
+	r.lastReloadSuccessTimestamp.Describe(ch)
+	r.configHash.Describe(ch)
+}
+
+// Collect returns the current state of reload metrics.
+func (r *reloader) Collect(ch chan<- prometheus.Metric) {
+	r.lastReloadSuccessful.Collect(ch)
+	r.lastReloadSuccessTimestamp.Collect(ch)
+	r.configHash.Collect(ch)
+}
+
+func (r *reloader) logResult(err error) {
+	if err != nil {
+		zap.S().Errorf("Failed to reload configuration, keeping the previous one: %v", err)
+		return
+	}
+
+	zap.S().Info("Configuration reloaded.")
+}
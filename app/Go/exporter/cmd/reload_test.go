@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"exporter/exporter/collector"
+	"exporter/exporter/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Serves the connection of a Redis which replies to every command with an empty INFO section once it's released.
+func serveBlockedRedis(conn net.Conn, received chan<- struct{}, release <-chan struct{}) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		// Commands are sent as arrays of bulk strings, every argument takes two lines.
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		args, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "*")))
+		for i := 0; i < 2*args; i++ {
+			_, err = reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+		}
+
+		received <- struct{}{}
+		<-release
+
+		section := "# Clients\r\n"
+		_, err = conn.Write([]byte("$" + strconv.Itoa(len(section)) + "\r\n" + section + "\r\n"))
+		if err != nil {
+			return
+		}
+	}
+}
+
+var _ = Describe("Configuration reload", func() {
+	var (
+		dir        string
+		connection *redisConnection
+		r          *reloader
+	)
+
+	// Writes the configuration file with the Redis address.
+	writeConfig := func(address string) {
+		content := "exporter_port: :9999\nredis_address: " + address + "\nredis_databases: [1]\nrequired_metrics: [Clients]\n"
+		Expect(ioutil.WriteFile(filepath.Join(dir, "configuration.yaml"), []byte(content), 0600)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "exporter")
+		Expect(err).To(BeNil())
+
+		writeConfig("localhost:1")
+		cfg, err := config.Load(dir, "configuration")
+		Expect(err).To(BeNil())
+
+		connection, err = setupRedisClient(cfg, nil, nil)
+		Expect(err).To(BeNil())
+
+		metricsCollector := collector.NewMetricsCollector(context.Background(), connection.client, cfg.SectionNames(), cfg.RedisDatabases)
+		r = newReloader(cfg, connection, nil, metricsCollector, zap.NewAtomicLevel())
+		r.path, r.name = dir, "configuration"
+	})
+
+	AfterEach(func() {
+		r.close()
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("Keeps the client when connection details don't change", func() {
+		Expect(r.reload()).To(Succeed())
+
+		Expect(r.connection).To(BeIdenticalTo(connection))
+		Expect(isClosed(connection)).To(BeFalse())
+		Expect(testutil.ToFloat64(r.lastReloadSuccessful)).To(Equal(1.0))
+	})
+
+	It("Keeps the previous client open when the configuration is invalid", func() {
+		writeConfig("")
+
+		Expect(r.reload()).NotTo(Succeed())
+
+		Expect(r.connection).To(BeIdenticalTo(connection))
+		Expect(r.cfg.RedisAddress).To(Equal("localhost:1"))
+		Expect(isClosed(connection)).To(BeFalse())
+		Expect(testutil.ToFloat64(r.lastReloadSuccessful)).To(Equal(0.0))
+	})
+
+	It("Closes the previous client when the address changes", func() {
+		writeConfig("localhost:2")
+
+		Expect(r.reload()).To(Succeed())
+
+		Expect(r.connection).NotTo(BeIdenticalTo(connection))
+		Expect(r.connection.key.address).To(Equal("localhost:2"))
+		Eventually(func() bool { return isClosed(connection) }).Should(BeTrue())
+		Expect(isClosed(r.connection)).To(BeFalse())
+	})
+
+	It("Closes the previous client after the running scrape finishes", func() {
+		// Redis replies to commands only after it's released, so the scrape is blocked until then.
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+		defer listener.Close()
+
+		received := make(chan struct{}, 10)
+		release := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+
+			for {
+				conn, err := listener.Accept()
+				if err != nil {
+					return
+				}
+				go serveBlockedRedis(conn, received, release)
+			}
+		}()
+
+		writeConfig(listener.Addr().String())
+		Expect(r.reload()).To(Succeed())
+		blocked := r.connection
+
+		registry := prometheus.NewRegistry()
+		Expect(r.collector.Register(registry)).To(Succeed())
+		scraped := make(chan struct{})
+		go func() {
+			defer GinkgoRecover()
+			defer close(scraped)
+
+			_, err := registry.Gather()
+			Expect(err).To(BeNil())
+		}()
+		Eventually(received).Should(Receive())
+
+		writeConfig("localhost:2")
+		Expect(r.reload()).To(Succeed())
+		Consistently(func() bool { return isClosed(blocked) }, 200*time.Millisecond).Should(BeFalse())
+
+		close(release)
+		Eventually(scraped).Should(BeClosed())
+		Expect(r.collector.LastScrape().Err).To(BeNil())
+		Eventually(func() bool { return isClosed(blocked) }).Should(BeTrue())
+	})
+
+	It("Reloads only on POST requests", func() {
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/-/reload", nil))
+		Expect(rr.Code).To(Equal(http.StatusMethodNotAllowed))
+
+		rr = httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("POST", "/-/reload", nil))
+		Expect(rr.Code).To(Equal(http.StatusOK))
+	})
+})
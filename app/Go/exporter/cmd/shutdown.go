@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Time given to in-flight requests to finish after the shutdown signal.
+const shutdownTimeout = 30 * time.Second
+
+// Waits for SIGTERM or SIGINT and gracefully stops the server, in-flight scrapes are drained until the timeout.
+// Resources are released after the server is stopped, the returned channel is closed after that.
+func shutdownOnSignal(server *http.Server, release func()) <-chan struct{} {
+	stopped := make(chan struct{})
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+
+	go func() {
+		sig := <-stop
+		zap.S().Infof("Received %s, shutting down.", sig)
+
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		err := server.Shutdown(ctx)
+		if err != nil {
+			zap.S().Warnf("Failed to drain in-flight requests: %v", err)
+		}
+
+		release()
+		close(stopped)
+	}()
+
+	return stopped
+}
@@ -0,0 +1,46 @@
+package collector
+
+import (
+	"exporter/exporter/breaker"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Returns the desc of the circuit breaker state with the constant labels.
+func newBreakerStateDesc(constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "exporter", "circuit_breaker_state"),
+		"State of the circuit breaker of Redis target, the current state has value 1.",
+		[]string{"state"}, constLabels,
+	)
+}
+
+// SetBreaker replaces the circuit breaker of Redis target, nil disables the breaker.
+// The breaker is replaced together with the client, so the state of the previous target is not carried over.
+func (collector *MetricsCollector) SetBreaker(b *breaker.Breaker) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.breaker = b
+}
+
+// Returns the state of the circuit breaker, nothing is returned when the breaker is disabled.
+func (collector *MetricsCollector) collectBreakerState(ch chan<- prometheus.Metric) {
+	collector.mu.RLock()
+	b := collector.breaker
+	desc := collector.descs.breakerState
+	collector.mu.RUnlock()
+
+	if b == nil {
+		return
+	}
+
+	current := b.State()
+	for _, state := range breaker.States {
+		value := 0.0
+		if state == current {
+			value = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value, state.String())
+	}
+}
@@ -0,0 +1,35 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/backoff"
+	"exporter/exporter/breaker"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Redis collector circuit breaker", func() {
+	var fixture *collectorFixture
+
+	BeforeEach(func() {
+		fixture = newCollectorFixture(context.Background(), []string{"Clients"}, []int{1})
+		fixture.metricsCollector.SetBreaker(breaker.New(1, backoff.Backoff{Min: time.Hour, Max: time.Hour}))
+
+		// Redis is queried only by the first scrape, the breaker opens after it.
+		fixture.mockClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(redis.NewStringResult("", redis.ErrClosed)).Times(2)
+	})
+
+	It("Reports Redis as down without querying it while the breaker is open", func() {
+		first := fixture.scrape()
+		second := fixture.scrape()
+
+		Expect(first).To(ContainSubstring("redis_up 0"))
+		Expect(first).To(ContainSubstring(`redis_exporter_circuit_breaker_state{state="open"} 1`))
+		Expect(second).To(ContainSubstring("redis_up 0"))
+		Expect(second).To(ContainSubstring(`redis_exporter_circuit_breaker_state{state="closed"} 0`))
+		Expect(fixture.metricsCollector.LastScrape().Err).To(MatchError(breaker.ErrOpen))
+	})
+})
@@ -0,0 +1,51 @@
+package collector
+
+// Numerical INFO fields of Redis 6 by section, descs of their metrics are built once and described up front.
+// Fields which are not listed are still exposed, but through the unchecked path, see Scrape.Unchecked.
+var infoCatalog = map[string][]string{
+	"Server": {
+		"redis_git_dirty", "arch_bits", "process_id", "tcp_port", "uptime_in_seconds", "uptime_in_days", "hz", "configured_hz", "lru_clock",
+	},
+	"Clients": {
+		"connected_clients", "client_recent_max_input_buffer", "client_recent_max_output_buffer", "client_longest_output_list",
+		"client_biggest_input_buf", "blocked_clients", "tracking_clients", "clients_in_timeout_table",
+	},
+	"Memory": {
+		"used_memory", "used_memory_rss", "used_memory_peak", "used_memory_overhead", "used_memory_startup", "used_memory_dataset",
+		"allocator_allocated", "allocator_active", "allocator_resident", "total_system_memory", "used_memory_lua", "used_memory_scripts",
+		"number_of_cached_scripts", "maxmemory", "allocator_frag_ratio", "allocator_frag_bytes", "allocator_rss_ratio", "allocator_rss_bytes",
+		"rss_overhead_ratio", "rss_overhead_bytes", "mem_fragmentation_ratio", "mem_fragmentation_bytes", "mem_not_counted_for_evict",
+		"mem_replication_backlog", "mem_clients_slaves", "mem_clients_normal", "mem_aof_buffer", "active_defrag_running", "lazyfree_pending_objects",
+	},
+	"Persistence": {
+		"loading", "rdb_changes_since_last_save", "rdb_bgsave_in_progress", "rdb_last_save_time", "rdb_last_bgsave_time_sec",
+		"rdb_current_bgsave_time_sec", "rdb_last_cow_size", "aof_enabled", "aof_rewrite_in_progress", "aof_rewrite_scheduled",
+		"aof_last_rewrite_time_sec", "aof_current_rewrite_time_sec", "aof_last_cow_size", "module_fork_in_progress", "module_fork_last_cow_size",
+	},
+	"Stats": {
+		"total_connections_received", "total_commands_processed", "instantaneous_ops_per_sec", "total_net_input_bytes", "total_net_output_bytes",
+		"instantaneous_input_kbps", "instantaneous_output_kbps", "rejected_connections", "sync_full", "sync_partial_ok", "sync_partial_err",
+		"expired_keys", "expired_stale_perc", "expired_time_cap_reached_count", "expire_cycle_cpu_milliseconds", "evicted_keys", "keyspace_hits",
+		"keyspace_misses", "pubsub_channels", "pubsub_patterns", "latest_fork_usec", "migrate_cached_sockets", "slave_expires_tracked_keys",
+		"active_defrag_hits", "active_defrag_misses", "active_defrag_key_hits", "active_defrag_key_misses", "tracking_total_keys",
+		"tracking_total_items", "tracking_total_prefixes", "unexpected_error_replies", "total_reads_processed", "total_writes_processed",
+		"io_threaded_reads_processed", "io_threaded_writes_processed",
+	},
+	"Replication": {
+		"connected_slaves", "master_repl_offset", "second_repl_offset", "repl_backlog_active", "repl_backlog_size",
+		"repl_backlog_first_byte_offset", "repl_backlog_histlen", "master_port", "master_last_io_seconds_ago", "master_sync_in_progress",
+		"slave_repl_offset", "slave_priority", "slave_read_only", "master_link_down_since_seconds",
+	},
+	"CPU": {
+		"used_cpu_sys", "used_cpu_user", "used_cpu_sys_children", "used_cpu_user_children",
+	},
+	"Cluster": {
+		"cluster_enabled",
+	},
+}
+
+// Numerical CLUSTER INFO fields, their metrics are named redis_cluster_<field without cluster_ prefix>.
+var clusterCatalog = []string{
+	"cluster_slots_assigned", "cluster_slots_ok", "cluster_slots_pfail", "cluster_slots_fail", "cluster_known_nodes", "cluster_size",
+	"cluster_current_epoch", "cluster_my_epoch", "cluster_stats_messages_sent", "cluster_stats_messages_received",
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	registerCollector("cluster", "State of Redis Cluster from CLUSTER INFO, it fails on instances without cluster support.", false, newClusterCollector)
+}
+
+// clusterCollector returns fields of CLUSTER INFO.
+type clusterCollector struct {
+	constLabels prometheus.Labels
+	state       *prometheus.Desc
+	// Descs of catalog fields by field names.
+	fields map[string]*prometheus.Desc
+}
+
+func newClusterCollector(constLabels prometheus.Labels) SubCollector {
+	c := &clusterCollector{
+		constLabels: constLabels,
+		state: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "cluster", "state"),
+			"Whether the cluster state is ok.",
+			nil, constLabels,
+		),
+		fields: make(map[string]*prometheus.Desc),
+	}
+
+	for _, field := range clusterCatalog {
+		c.fields[field] = c.fieldDesc(field)
+	}
+
+	return c
+}
+
+// Returns the desc of the CLUSTER INFO field metric.
+func (c *clusterCollector) fieldDesc(field string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "cluster", strings.TrimPrefix(field, "cluster_")),
+		"Data gathered from Redis CLUSTER INFO.",
+		nil, c.constLabels,
+	)
+}
+
+// Describe writes descs of the cluster state and catalog fields, they are named the same in all naming schemes.
+func (c *clusterCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.state
+	for _, desc := range c.fields {
+		ch <- desc
+	}
+}
+
+// Commands returns CLUSTER INFO command.
+func (c *clusterCollector) Commands(databases []int) [][]interface{} {
+	return [][]interface{}{{"CLUSTER", "INFO"}}
+}
+
+// Parse returns fields of CLUSTER INFO.
+func (c *clusterCollector) Parse(cmds []*redis.Cmd) (interface{}, error) {
+	info, err := cmds[0].Text()
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make(map[string]string)
+	for _, line := range strings.Split(strings.Replace(info, "\r\n", "\n", -1), "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			fields[parts[0]] = parts[1]
+		}
+	}
+
+	return fields, nil
+}
+
+// Update returns the cluster state and numerical CLUSTER INFO fields as redis_cluster_<field> metrics.
+func (c *clusterCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	fields, ok := scrape.Data.(map[string]string)
+	if !ok {
+		return nil
+	}
+
+	if state, ok := fields["cluster_state"]; ok {
+		value := 0.0
+		if state == "ok" {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, value)
+	}
+
+	numerical := make(map[string]string)
+	for k, v := range fields {
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			numerical[k] = v
+		}
+	}
+
+	// Names are unique after sanitization, but they may still clash once the cluster_ prefix is trimmed.
+	emitted := map[string]bool{"state": true}
+	for _, field := range sanitizeFields(scrape, numerical, map[string]bool{"cluster_state": true}) {
+		name := strings.TrimPrefix(field.name, "cluster_")
+		if emitted[name] {
+			scrape.Drop(field.field, dropCollision)
+			continue
+		}
+		emitted[name] = true
+
+		val, _ := strconv.ParseFloat(field.value, 64)
+
+		// Fields which are not in the catalog can't be described up front.
+		if desc, ok := c.fields[field.field]; ok {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, val)
+		} else {
+			scrape.Unchecked <- prometheus.MustNewConstMetric(c.fieldDesc(field.name), prometheus.GaugeValue, val)
+		}
+	}
+
+	return nil
+}
@@ -2,166 +2,407 @@ package collector
 
 import (
 	"context"
+	"exporter/exporter/breaker"
 	"exporter/exporter/client"
-	"exporter/exporter/parser"
+	"exporter/exporter/relabel"
+	"exporter/exporter/tracing"
 	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
 	"strconv"
+	"sync"
+	"time"
 )
 
 const namespace = "redis"
 
-var (
-	// Metrics
-	clientsConnectedTotal = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "", "clients_connected_total"),
-		"Total number of clients connected to Redis.",
-		nil, nil,
-	)
-	keysPerDatabaseCount = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "", "keys_per_database_count"),
-		"Number of keys per Redis database.",
-		[]string{"database"}, nil,
-	)
-	expiringKeysCount = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "", "expiring_keys_count"),
-		"Number of keys per Redis database.",
-		[]string{"database"}, nil,
-	)
-	averageKeyTTLSeconds = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "", "average_key_ttl_seconds"),
-		"Average key TTL in seconds.",
-		[]string{"database"}, nil,
-	)
-)
+// descriptors holds descs of metrics of the collector itself, they are rebuilt when constant labels change.
+type descriptors struct {
+	up                 *prometheus.Desc
+	snapshotAgeSeconds *prometheus.Desc
+	breakerState       *prometheus.Desc
+	collectorSuccess   *prometheus.Desc
+	collectorDuration  *prometheus.Desc
+}
+
+// Returns descs of metrics of the collector itself with the constant labels.
+func newDescriptors(constLabels prometheus.Labels) *descriptors {
+	return &descriptors{
+		up: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "up"),
+			"Whether the last query of Redis was successful.",
+			nil, constLabels,
+		),
+		snapshotAgeSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", "snapshot_age_seconds"),
+			"Age of the Redis data snapshot served in background polling mode.",
+			nil, constLabels,
+		),
+		breakerState: newBreakerStateDesc(constLabels),
+		collectorSuccess: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", "collector_success"),
+			"Whether the sub-collector succeeded in the last scrape.",
+			[]string{"collector"}, constLabels,
+		),
+		collectorDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "exporter", "collector_duration_seconds"),
+			"Duration of the sub-collector in the last scrape, including its own Redis queries.",
+			[]string{"collector"}, constLabels,
+		),
+	}
+}
+
+// ScrapeStatus describes the result of a scrape.
+type ScrapeStatus struct {
+	Time     time.Time
+	Duration time.Duration
+	Err      error
+}
 
 type MetricsCollector struct {
-	ctx                   context.Context
-	clients               client.SliceOfClients
-	requiredMetrics       []string
-	databases             []int
-	clientsConnectedTotal *prometheus.Desc
-	keysPerDatabaseCount  *prometheus.Desc
-	expiringKeysCount     *prometheus.Desc
-	averageKeyTTLSeconds  *prometheus.Desc
+	ctx             context.Context
+	mu              sync.RWMutex
+	redisClient     client.RedisClient
+	clientScrapes   *sync.WaitGroup
+	target          string
+	requiredMetrics []string
+	databases       []int
+	lastScrape      ScrapeStatus
+	scrapes         singleflight.Group
+	polling         bool
+	snapshot        *snapshot
+	polledUp        bool
+	sections        sectionCache
+	scrapeOptions   ScrapeOptions
+	breaker         *breaker.Breaker
+	relabeler       *relabel.Relabeler
+	naming          NamingScheme
+	selfMetrics     bool
+	constLabels     prometheus.Labels
+	descs           *descriptors
+	collectorNames  []string
+	subCollectors   []namedCollector
+	sectionErrors   *prometheus.CounterVec
+	droppedFields   *prometheus.CounterVec
+	events          *eventTracker
+	sectionDuration *prometheus.HistogramVec
 }
 
 // NewMetricsCollector allocates a new collector instance.
-func NewMetricsCollector(ctx context.Context, clients client.SliceOfClients, requiredMetrics []string, databases []int) *MetricsCollector {
+func NewMetricsCollector(ctx context.Context, redisClient client.RedisClient, requiredMetrics []string, databases []int) *MetricsCollector {
+	// Registered collectors are known, so default ones are always built.
+	names := DefaultCollectors()
+	subCollectors, _ := newSubCollectors(names, nil)
+
 	return &MetricsCollector{
-		ctx:                   ctx,
-		clients:               clients,
-		databases:             databases,
-		requiredMetrics:       requiredMetrics,
-		clientsConnectedTotal: clientsConnectedTotal,
-		keysPerDatabaseCount:  keysPerDatabaseCount,
-		expiringKeysCount:     expiringKeysCount,
-		averageKeyTTLSeconds:  averageKeyTTLSeconds,
+		ctx:             ctx,
+		redisClient:     redisClient,
+		clientScrapes:   &sync.WaitGroup{},
+		databases:       databases,
+		requiredMetrics: requiredMetrics,
+		descs:           newDescriptors(nil),
+		collectorNames:  names,
+		subCollectors:   subCollectors,
+		sectionErrors:   newSectionErrors(nil),
+		droppedFields:   newDroppedFields(nil),
+		events:          newEventTracker(),
+		sectionDuration: newSectionDuration(nil),
 	}
 }
 
-// Describe writes all descriptors to the Prometheus desc channel.
-func (collector *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
-	ch <- collector.clientsConnectedTotal
-	ch <- collector.keysPerDatabaseCount
-	ch <- collector.expiringKeysCount
-	ch <- collector.averageKeyTTLSeconds
+// Returns the counter of failed INFO section queries with the constant labels.
+func newSectionErrors(constLabels prometheus.Labels) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   "exporter",
+		Name:        "section_errors_total",
+		Help:        "Number of failed INFO section queries by reason, either timeout or error.",
+		ConstLabels: constLabels,
+	}, []string{"section", "reason"})
 }
 
-// Collect implements required collect function for all Prometheus collectors
-func (collector *MetricsCollector) Collect(ch chan<- prometheus.Metric) {
-	// Any of clients from same Redis connection works well to provide collector with general and keyspace data from INFO.
-	generalMetrics, err := parser.GetInfoMetrics(collector.ctx, collector.requiredMetrics, collector.clients.RedisClients[0])
-	if err != nil {
-		zap.S().Panic(err)
+// Returns the histogram of INFO section query durations with the constant labels.
+func newSectionDuration(constLabels prometheus.Labels) *prometheus.HistogramVec {
+	return prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace:   namespace,
+		Subsystem:   "exporter",
+		Name:        "section_duration_seconds",
+		Help:        "Duration of INFO section queries, sections queried in a pipeline get the duration of the whole pipeline.",
+		Buckets:     []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		ConstLabels: constLabels,
+	}, []string{"section"})
+}
+
+// EnableSelfMetrics makes the collector expose durations of section queries.
+func (collector *MetricsCollector) EnableSelfMetrics() {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.selfMetrics = true
+}
+
+// SetTarget sets the address of Redis target, which is added to logs of the collector.
+func (collector *MetricsCollector) SetTarget(target string) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	// Events are detected by comparing snapshots of the same target.
+	if target != collector.target {
+		collector.events = newEventTracker()
 	}
+	collector.target = target
+}
 
-	keyspaceMetrics, err := parser.GetKeyspaceMetrics(collector.ctx, collector.clients.RedisClients[0])
-	if err != nil {
-		zap.S().Panic(err)
+// Returns the logger with the address of Redis target.
+func (collector *MetricsCollector) logger() *zap.Logger {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	return zap.L().With(zap.String("target", collector.target))
+}
+
+// SetRelabeler replaces relabeling rules and filters applied to the collected metrics, nil disables relabeling.
+func (collector *MetricsCollector) SetRelabeler(relabeler *relabel.Relabeler) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.relabeler = relabeler
+}
+
+// Returns the relabeler of the collected metrics.
+func (collector *MetricsCollector) currentRelabeler() *relabel.Relabeler {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	return collector.relabeler
+}
+
+// UpdateSettings atomically replaces the client and parser settings used by the collector.
+// Scrapes which are already running finish with the previous settings, the returned channel is closed
+// when they are done, so the previous client can be closed without failing them.
+func (collector *MetricsCollector) UpdateSettings(redisClient client.RedisClient, requiredMetrics []string, databases []int) <-chan struct{} {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	// Scrapes join the group of the client under the read lock, so no scrape joins the previous group after the swap.
+	previous := collector.clientScrapes
+	done := make(chan struct{})
+	go func() {
+		previous.Wait()
+		close(done)
+	}()
+
+	collector.redisClient = redisClient
+	collector.clientScrapes = &sync.WaitGroup{}
+	collector.requiredMetrics = requiredMetrics
+	collector.databases = databases
+
+	// Cached results may come from the previous client.
+	collector.sections.reset()
+
+	return done
+}
+
+// LastScrape returns the result of the last finished scrape, zero status is returned before the first scrape.
+func (collector *MetricsCollector) LastScrape() ScrapeStatus {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	return collector.lastScrape
+}
+
+// Stores the result of the scrape which started at the given time.
+func (collector *MetricsCollector) recordScrape(start time.Time, err error) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.lastScrape = ScrapeStatus{
+		Time:     start,
+		Duration: time.Since(start),
+		Err:      err,
 	}
+}
 
-	// Non-numerical values cannot be set as values for Prometheus metrics.
-	// Store this exceptional data and return it later as labels for metric.
-	stringMetricsKeys := []string{}
-	stringMetricsValues := []string{}
+// Describe writes descriptors of all metrics known before the scrape to the Prometheus desc channel.
+// The collector is registered with Register, which collects metrics of fields which are not known up front through an unchecked collector.
+func (collector *MetricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	collector.mu.RLock()
+	descs := collector.descs
+	sectionErrors := collector.sectionErrors
+	sectionDuration := collector.sectionDuration
+	droppedFields := collector.droppedFields
+	collector.mu.RUnlock()
 
-	// Iterate over all metrics.
-	for k, v := range *generalMetrics {
-		val, err := strconv.ParseFloat(v, 64)
-		if err != nil {
-			stringMetricsKeys = append(stringMetricsKeys, k)
-			stringMetricsValues = append(stringMetricsValues, v)
-			continue
-		}
+	ch <- descs.up
+	ch <- descs.snapshotAgeSeconds
+	ch <- descs.breakerState
+	ch <- descs.collectorSuccess
+	ch <- descs.collectorDuration
+	sectionErrors.Describe(ch)
+	sectionDuration.Describe(ch)
+	droppedFields.Describe(ch)
+	collector.describeSubCollectors(ch)
+}
+
+// Collects metrics of a scrape, metrics with described descs are sent to ch, other metrics to unchecked.
+func (collector *MetricsCollector) collect(ctx context.Context, ch chan<- prometheus.Metric, unchecked chan<- prometheus.Metric) {
+	collector.mu.RLock()
+	polling := collector.polling
+	sectionErrors := collector.sectionErrors
+	droppedFields := collector.droppedFields
+	collector.mu.RUnlock()
 
-		numericalMetric := prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, "info", k),
-			"Data gathered from Redis INFO.",
-			nil, nil,
-		)
+	// Errors, dropped fields and breaker state are changed by the scrape, so they are collected after it.
+	defer sectionErrors.Collect(ch)
+	defer droppedFields.Collect(ch)
+	defer collector.collectBreakerState(ch)
+	defer collector.collectSelfMetrics(ch)
 
-		// Return all numerical metrics.
-		ch <- prometheus.MustNewConstMetric(numericalMetric, prometheus.GaugeValue, val)
+	if polling {
+		collector.collectCached(ch, unchecked)
+		return
 	}
 
-	// Return all non-numeric metrics as labels.
-	stringMetric := prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, "info", "non_numerical"),
-		"Non-numerical data gathered from Redis INFO.",
-		stringMetricsKeys, nil,
-	)
+	// Concurrent scrapes share the result of a single Redis query. The query doesn't run with the context
+	// of the first caller, so its cancellation doesn't fail the scrape of other callers.
+	results := collector.scrapes.DoChan("scrape", func() (interface{}, error) {
+		scrapeCtx, cancel := collector.detachedContext(ctx)
+		defer cancel()
 
-	ch <- prometheus.MustNewConstMetric(stringMetric, prometheus.GaugeValue, 1, stringMetricsValues...)
+		return collector.scrape(scrapeCtx)
+	})
 
-	// Return required common custom metric.
-	ch <- prometheus.MustNewConstMetric(collector.clientsConnectedTotal, prometheus.GaugeValue, getClientsConnectedTotal(*generalMetrics))
+	var s *snapshot
+	select {
+	case result := <-results:
+		if result.Err != nil {
+			collector.logger().Error("Failed to query Redis", zap.Error(result.Err))
+		}
+		s = result.Val.(*snapshot)
+	case <-ctx.Done():
+		// The caller gave up, the shared query keeps running for other callers.
+		collector.logger().Error("Failed to query Redis", zap.Error(ctx.Err()))
+	}
 
-	// Return required metrics for all configured databases.
-	for i, v := range *keyspaceMetrics {
-		db := strconv.Itoa(collector.databases[i])
-		ch <- prometheus.MustNewConstMetric(collector.keysPerDatabaseCount, prometheus.GaugeValue, getKeysPerDatabaseCount(v), db)
-		ch <- prometheus.MustNewConstMetric(collector.expiringKeysCount, prometheus.GaugeValue, getExpiringKeysCount(v), db)
-		ch <- prometheus.MustNewConstMetric(collector.averageKeyTTLSeconds, prometheus.GaugeValue, getAverageKeyTTLSeconds(v), db)
+	// Data of sections which didn't fail is returned even if some sections failed.
+	collector.emitUp(s != nil, ch)
+	if s != nil {
+		collector.emit(s, ch, unchecked)
 	}
 }
 
-func getClientsConnectedTotal(metrics map[string]string) float64 {
-	metric := "connected_clients"
-	val, err := strconv.ParseFloat(metrics[metric], 64)
-	if err != nil {
-		zap.S().Panic("Failed to read metric:", metric, err)
+// Returns a context of the collector for a query shared by concurrent scrapes. It's bounded by the deadline
+// of the scrape context and keeps its trace span, but it's not cancelled together with the scrape.
+func (collector *MetricsCollector) detachedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	// Collect scrapes with the collector context, it's not bound to a single scrape.
+	if ctx == collector.ctx {
+		return ctx, func() {}
+	}
+
+	detached := trace.ContextWithSpan(collector.ctx, trace.SpanFromContext(ctx))
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(detached)
 	}
 
-	return val
+	return context.WithDeadline(detached, deadline)
 }
 
-func getKeysPerDatabaseCount(metrics map[string]string) float64 {
-	metric := "keys"
-	val, err := strconv.ParseFloat(metrics[metric], 64)
-	if err != nil {
-		zap.S().Panic("Failed to read metric:", metric, err)
+// Queries Redis for the data of all required metrics and records the scrape status.
+func (collector *MetricsCollector) scrape(ctx context.Context) (*snapshot, error) {
+	start := time.Now()
+
+	// Take a snapshot of the settings, so a concurrent reload doesn't affect the running scrape.
+	collector.mu.RLock()
+	redisClient := collector.redisClient
+	clientScrapes := collector.clientScrapes
+	clientScrapes.Add(1)
+	requiredMetrics := collector.requiredMetrics
+	databases := collector.databases
+	options := collector.scrapeOptions
+	b := collector.breaker
+	target := collector.target
+	subCollectors := collector.subCollectors
+	events := collector.events
+	collector.mu.RUnlock()
+	defer clientScrapes.Done()
+
+	logger := zap.L().With(zap.String("target", target))
+	ctx, span := tracing.Start(ctx, "scrape", trace.WithAttributes(label.String("redis.target", target)))
+
+	// Open breaker short-circuits the scrape, so scrapes of a failing target don't wait for timeouts.
+	if b != nil {
+		err := b.Allow()
+		if err != nil {
+			collector.recordScrape(start, err)
+			tracing.End(span, err)
+			return nil, err
+		}
+	}
+
+	// General and keyspace data of all databases is provided by INFO of the default database.
+	// Sections with refresh intervals are taken from the cache while their results are fresh.
+	// Commands of sub-collectors are sent together with sections, their failures don't fail the scrape.
+	s, err := collector.fetch(ctx, logger, requiredMetrics, databases, fetchersOf(subCollectors), options, redisClient)
+	tracing.End(span, err)
+	collector.recordScrape(start, err)
+	logger.Debug("Scraped Redis", zap.Duration("duration", time.Since(start)), zap.Error(err))
+	if b != nil {
+		if s == nil {
+			b.Failure()
+		} else {
+			b.Success()
+		}
+	}
+
+	if s == nil {
+		return nil, err
 	}
 
-	return val
+	s.time = start
+	s.databases = databases
+	events.observe(s, logger)
+
+	return s, err
 }
 
-func getExpiringKeysCount(metrics map[string]string) float64 {
-	metric := "expires"
-	val, err := strconv.ParseFloat(metrics[metric], 64)
-	if err != nil {
-		zap.S().Panic("Failed to read metric:", metric, err)
+// Returns durations of section queries if self metrics are enabled.
+func (collector *MetricsCollector) collectSelfMetrics(ch chan<- prometheus.Metric) {
+	collector.mu.RLock()
+	selfMetrics := collector.selfMetrics
+	sectionDuration := collector.sectionDuration
+	collector.mu.RUnlock()
+
+	if selfMetrics {
+		sectionDuration.Collect(ch)
+	}
+}
+
+// Returns whether Redis was queried successfully.
+func (collector *MetricsCollector) emitUp(ok bool, ch chan<- prometheus.Metric) {
+	value := 0.0
+	if ok {
+		value = 1
 	}
 
-	return val
+	ch <- prometheus.MustNewConstMetric(collector.descriptors().up, prometheus.GaugeValue, value)
+}
+
+// Returns metrics built from the snapshot by enabled sub-collectors.
+func (collector *MetricsCollector) emit(s *snapshot, ch chan<- prometheus.Metric, unchecked chan<- prometheus.Metric) {
+	collector.updateSubCollectors(s, ch, unchecked)
 }
 
-func getAverageKeyTTLSeconds(metrics map[string]string) float64 {
-	metric := "avg_ttl"
-	val, err := strconv.ParseFloat(metrics[metric], 64)
+// Returns the metric with the value of the field, fields with non-numerical values are logged and left out.
+func emitField(ch chan<- prometheus.Metric, logger *zap.Logger, desc *prometheus.Desc, section string, metrics map[string]string, field string, labelValues ...string) {
+	val, err := strconv.ParseFloat(metrics[field], 64)
 	if err != nil {
-		zap.S().Panic("Failed to read metric:", metric, err)
+		logger.Debug("Failed to read metric", zap.String("section", section), zap.String("field", field), zap.Error(err))
+		return
 	}
 
-	return val
+	ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, val, labelValues...)
 }
@@ -1,6 +1,13 @@
 package collector_test
 
 import (
+	"context"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	. "github.com/onsi/ginkgo"
@@ -11,3 +18,31 @@ func TestCollector(t *testing.T) {
 	RegisterFailHandler(Fail)
 	RunSpecs(t, "Collector Suite")
 }
+
+// collectorFixture is a collector with a mocked Redis client, which is scraped through its HTTP handler.
+type collectorFixture struct {
+	mockClient       *mocks.MockRedisClient
+	metricsCollector *collector.MetricsCollector
+	handler          http.Handler
+}
+
+// Returns the fixture of a collector which queries the required metrics of the databases with the context.
+func newCollectorFixture(ctx context.Context, requiredMetrics []string, databases []int) *collectorFixture {
+	mockClient := mocks.NewMockRedisClient(gomock.NewController(GinkgoT()))
+	metricsCollector := collector.NewMetricsCollector(ctx, mockClient, requiredMetrics, databases)
+
+	return &collectorFixture{
+		mockClient:       mockClient,
+		metricsCollector: metricsCollector,
+		handler:          collector.NewHandler(metricsCollector, prometheus.NewRegistry()),
+	}
+}
+
+// Returns the response body of a scrape.
+func (fixture *collectorFixture) scrape() string {
+	rr := httptest.NewRecorder()
+	fixture.handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	Expect(rr.Code).To(Equal(http.StatusOK))
+
+	return rr.Body.String()
+}
@@ -2,7 +2,6 @@ package collector_test
 
 import (
 	"context"
-	"exporter/exporter/client"
 	"exporter/exporter/client/mocks"
 	"exporter/exporter/collector"
 	"github.com/go-redis/redis/v8"
@@ -16,16 +15,13 @@ import (
 )
 
 // Test exporter with 3 databases configured.
-// Exporter fetches as many databases as configured.
+// Exporter fetches as many databases as configured with a single client.
 var _ = Describe("Redis collector Prometheus exporter", func() {
 	var (
 		mockCtrl         *gomock.Controller
 		ctx              context.Context
-		mockClients      client.SliceOfClients
 		metricsCollector *collector.MetricsCollector
-		mockClient1      *mocks.MockRedisClient
-		mockClient2      *mocks.MockRedisClient
-		mockClient3      *mocks.MockRedisClient
+		mockClient       *mocks.MockRedisClient
 		handler          http.Handler
 	)
 
@@ -34,21 +30,15 @@ var _ = Describe("Redis collector Prometheus exporter", func() {
 			mockCtrl = gomock.NewController(GinkgoT())
 			ctx = context.Background()
 
-			// Set up test mocked Redis clients.
-			mockClients = client.SliceOfClients{}
-			mockClient1 = mocks.NewMockRedisClient(mockCtrl)
-			mockClient2 = mocks.NewMockRedisClient(mockCtrl)
-			mockClient3 = mocks.NewMockRedisClient(mockCtrl)
-			mockClients.RedisClients = append(mockClients.RedisClients, mockClient1)
-			mockClients.RedisClients = append(mockClients.RedisClients, mockClient2)
-			mockClients.RedisClients = append(mockClients.RedisClients, mockClient3)
+			// Set up test mocked Redis client.
+			mockClient = mocks.NewMockRedisClient(mockCtrl)
 
-			// Set up collector to use mocked Redis clients.
-			metricsCollector = collector.NewMetricsCollector(ctx, mockClients, []string{"Keyspace", "Clients", "Memory"}, []int{1, 2, 3})
+			// Set up collector to use mocked Redis client.
+			metricsCollector = collector.NewMetricsCollector(ctx, mockClient, []string{"Keyspace", "Clients", "Memory"}, []int{1, 2, 3})
 
 			// Get rid of any additional metrics, it should expose only required metrics with a custom registry
 			r := prometheus.NewRegistry()
-			r.MustRegister(metricsCollector)
+			Expect(metricsCollector.Register(r)).To(Succeed())
 			handler = promhttp.HandlerFor(r, promhttp.HandlerOpts{})
 		})
 
@@ -59,9 +49,9 @@ var _ = Describe("Redis collector Prometheus exporter", func() {
 				keyspaceResponse := redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\ndb2:keys=1,expires=0,avg_ttl=0\ndb3:keys=1,expires=0,avg_ttl=0\n", nil)
 				memoryResponse := redis.NewStringResult("# Memory\nused_memory:862632\nused_memory_human:842.41K\nused_memory_rss:7655424\nused_memory_rss_human:7.30M\nused_memory_peak:945504\nused_memory_peak_human:923.34K\ntotal_system_memory:13347020800\ntotal_system_memory_human:12.43G\nused_memory_lua:37888\nused_memory_lua_human:37.00K\nmaxmemory:0\nmaxmemory_human:0B\nmaxmemory_policy:noeviction\nmem_fragmentation_ratio:8.87\nmem_allocator:jemalloc-4.0.3\n", nil)
 
-				mockClient1.EXPECT().Info(ctx, "Clients").Return(clientsResponse)
-				mockClient1.EXPECT().Info(ctx, "Keyspace").Return(keyspaceResponse)
-				mockClient1.EXPECT().Info(ctx, "Memory").Return(memoryResponse)
+				mockClient.EXPECT().Info(ctx, "Clients").Return(clientsResponse)
+				mockClient.EXPECT().Info(ctx, "Keyspace").Return(keyspaceResponse)
+				mockClient.EXPECT().Info(ctx, "Memory").Return(memoryResponse)
 
 			})
 			It("Returns Prometheus-formatted metrics", func() {
@@ -73,6 +63,35 @@ var _ = Describe("Redis collector Prometheus exporter", func() {
 
 				Expect(rr.Body.String()).To(Equal(getExpectedData()))
 				Expect(rr.Code).To(Equal(http.StatusOK))
+
+				Expect(metricsCollector.LastScrape().Time).NotTo(BeZero())
+				Expect(metricsCollector.LastScrape().Err).To(BeNil())
+			})
+		})
+
+		When("Collector settings were updated", func() {
+			BeforeEach(func() {
+				// Only the new client is expected to be queried after the update.
+				updatedClient := mocks.NewMockRedisClient(mockCtrl)
+				metricsCollector.UpdateSettings(updatedClient, []string{"Clients"}, []int{4})
+
+				clientsResponse := redis.NewStringResult("# Clients\nconnected_clients:5\n", nil)
+				keyspaceResponse := redis.NewStringResult("# Keyspace\ndb4:keys=7,expires=1,avg_ttl=10\n", nil)
+
+				updatedClient.EXPECT().Info(ctx, "Clients").Return(clientsResponse)
+				updatedClient.EXPECT().Info(ctx, "Keyspace").Return(keyspaceResponse)
+			})
+			It("Returns metrics collected with the new settings", func() {
+				req, err := http.NewRequest("GET", "/metrics", nil)
+				Expect(err).To(BeNil())
+
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+
+				Expect(rr.Body.String()).To(ContainSubstring("redis_clients_connected_total 5"))
+				Expect(rr.Body.String()).To(ContainSubstring(`redis_keys_per_database_count{database="4"} 7`))
+				Expect(rr.Body.String()).NotTo(ContainSubstring("redis_info_used_memory"))
+				Expect(rr.Code).To(Equal(http.StatusOK))
 			})
 		})
 	})
@@ -133,5 +152,8 @@ redis_info_used_memory_rss 7.655424e+06
 redis_keys_per_database_count{database="1"} 2
 redis_keys_per_database_count{database="2"} 1
 redis_keys_per_database_count{database="3"} 1
+# HELP redis_up Whether the last query of Redis was successful.
+# TYPE redis_up gauge
+redis_up 1
 `
 }
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prefix of commandstats fields, e.g. cmdstat_get:calls=1,usec=2,usec_per_call=2.00.
+const commandStatsPrefix = "cmdstat_"
+
+func init() {
+	registerCollector("commandstats", "Calls and duration per command from the Commandstats INFO section.", false, newCommandStatsCollector)
+}
+
+// commandStatsCollector returns call counts and durations per command when Commandstats section is queried.
+type commandStatsCollector struct {
+	commands         *prometheus.Desc
+	commandsDuration *prometheus.Desc
+}
+
+func newCommandStatsCollector(constLabels prometheus.Labels) SubCollector {
+	return &commandStatsCollector{
+		commands: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "commands_total"),
+			"Total number of calls per command",
+			[]string{"cmd"}, constLabels,
+		),
+		commandsDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "commands_duration_seconds_total"),
+			"Total amount of time in seconds spent per command",
+			[]string{"cmd"}, constLabels,
+		),
+	}
+}
+
+// Describe writes descs of command metrics, they are named the same in all naming schemes.
+func (c *commandStatsCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.commands
+	ch <- c.commandsDuration
+}
+
+// Update returns call count and total duration of every command from its commandstats field.
+func (c *commandStatsCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	// Fields are emitted in a stable order, so logs of fields which can't be read don't depend on map iteration.
+	var fields []string
+	for k := range scrape.Info {
+		if strings.HasPrefix(k, commandStatsPrefix) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, k := range fields {
+		stats := parseCommaSeparated(scrape.Info[k])
+
+		calls, err := strconv.ParseFloat(stats["calls"], 64)
+		if err != nil {
+			scrape.Logger.Debug("Failed to read metric", zap.String("section", "Commandstats"), zap.String("field", k), zap.Error(err))
+			continue
+		}
+
+		usec, err := strconv.ParseFloat(stats["usec"], 64)
+		if err != nil {
+			scrape.Logger.Debug("Failed to read metric", zap.String("section", "Commandstats"), zap.String("field", k), zap.Error(err))
+			continue
+		}
+
+		cmd := sanitizeLabelValue(strings.TrimPrefix(k, commandStatsPrefix))
+		ch <- prometheus.MustNewConstMetric(c.commands, prometheus.CounterValue, calls, cmd)
+		ch <- prometheus.MustNewConstMetric(c.commandsDuration, prometheus.CounterValue, usec/1e6, cmd)
+	}
+
+	return nil
+}
+
+// Parses values like calls=1,usec=2 into a map.
+func parseCommaSeparated(value string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) == 2 {
+			values[parts[0]] = parts[1]
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+func init() {
+	registerCollector("config", "Memory and client limits from CONFIG GET, it fails on instances with CONFIG disabled.", false, newConfigCollector)
+}
+
+// Configuration parameters queried with CONFIG GET in their order.
+var configParameters = []string{"maxmemory", "maxclients"}
+
+// configCollector returns limits from the Redis configuration.
+type configCollector struct {
+	// Descs of parameters by their names.
+	params map[string]*prometheus.Desc
+}
+
+func newConfigCollector(constLabels prometheus.Labels) SubCollector {
+	c := &configCollector{params: make(map[string]*prometheus.Desc)}
+	for _, param := range configParameters {
+		c.params[param] = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "config", param),
+			"Value of the "+param+" parameter from Redis CONFIG GET.",
+			nil, constLabels,
+		)
+	}
+
+	return c
+}
+
+// Describe writes descs of configuration parameters, they are named the same in all naming schemes.
+func (c *configCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	for _, param := range configParameters {
+		ch <- c.params[param]
+	}
+}
+
+// Commands returns CONFIG GET command of every parameter.
+func (c *configCollector) Commands(databases []int) [][]interface{} {
+	cmds := make([][]interface{}, 0, len(configParameters))
+	for _, param := range configParameters {
+		cmds = append(cmds, []interface{}{"CONFIG", "GET", param})
+	}
+
+	return cmds
+}
+
+// Parse returns values of the parameters by their names.
+func (c *configCollector) Parse(cmds []*redis.Cmd) (interface{}, error) {
+	values := make(map[string]float64, len(cmds))
+	for _, cmd := range cmds {
+		reply, err := cmd.Result()
+		if err != nil {
+			return nil, err
+		}
+
+		// Reply holds pairs of parameter names and values.
+		pair, ok := reply.([]interface{})
+		if !ok || len(pair) != 2 {
+			return nil, errors.New("unexpected CONFIG GET reply")
+		}
+
+		name, nameOk := pair[0].(string)
+		value, valueOk := pair[1].(string)
+		if !nameOk || !valueOk {
+			return nil, errors.New("unexpected CONFIG GET reply")
+		}
+
+		val, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[name] = val
+	}
+
+	return values, nil
+}
+
+// Update returns values of the parameters.
+func (c *configCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	values, ok := scrape.Data.(map[string]float64)
+	if !ok {
+		return nil
+	}
+
+	for _, param := range configParameters {
+		if value, ok := values[param]; ok {
+			ch <- prometheus.MustNewConstMetric(c.params[param], prometheus.GaugeValue, value)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package collector
+
+import (
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+func init() {
+	registerCollector("dbsize", "Number of keys of configured databases from DBSIZE, databases without keys included.", false, newDBSizeCollector)
+}
+
+// Returns DBSIZE of the database passed as the argument. The database selected by the script doesn't change the one of the connection.
+const dbSizeScript = `
+redis.call('SELECT', ARGV[1])
+return redis.call('DBSIZE')
+`
+
+// dbSizeCollector returns the number of keys of every configured database.
+type dbSizeCollector struct {
+	keys *prometheus.Desc
+}
+
+func newDBSizeCollector(constLabels prometheus.Labels) SubCollector {
+	return &dbSizeCollector{
+		keys: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "dbsize_keys"),
+			"Number of keys per Redis database from DBSIZE.",
+			[]string{"database"}, constLabels,
+		),
+	}
+}
+
+// Describe writes the desc of the number of keys, it's named the same in all naming schemes.
+func (c *dbSizeCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.keys
+}
+
+// Commands returns DBSIZE of every database.
+func (c *dbSizeCollector) Commands(databases []int) [][]interface{} {
+	cmds := make([][]interface{}, 0, len(databases))
+	for _, database := range databases {
+		cmds = append(cmds, []interface{}{"EVAL", dbSizeScript, 0, database})
+	}
+
+	return cmds
+}
+
+// Parse returns the number of keys of the databases in their order.
+func (c *dbSizeCollector) Parse(cmds []*redis.Cmd) (interface{}, error) {
+	sizes := make([]int64, 0, len(cmds))
+	for _, cmd := range cmds {
+		keys, err := cmd.Int64()
+		if err != nil {
+			return nil, err
+		}
+		sizes = append(sizes, keys)
+	}
+
+	return sizes, nil
+}
+
+// Update returns the number of keys of the databases. Fetched data is dropped when databases change,
+// so sizes are in the order of the scrape databases.
+func (c *dbSizeCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	sizes, ok := scrape.Data.([]int64)
+	if !ok || len(sizes) != len(scrape.Databases) {
+		return nil
+	}
+
+	for i, database := range scrape.Databases {
+		ch <- prometheus.MustNewConstMetric(c.keys, prometheus.GaugeValue, float64(sizes[i]), strconv.Itoa(database))
+	}
+
+	return nil
+}
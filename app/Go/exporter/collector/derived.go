@@ -0,0 +1,124 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+func init() {
+	registerCollector("derived", "Ratios and overheads computed from INFO and keyspace fields, e.g. keyspace hit ratio.", false, newDerivedCollector)
+}
+
+// derivedCollector returns metrics which are not reported by Redis, but computed by the exporter from other fields.
+// They save dashboards from repeating the same expressions, metrics are left out when their fields are not queried.
+type derivedCollector struct {
+	keyspaceHitRatio               *prometheus.Desc
+	memoryUtilizationRatio         *prometheus.Desc
+	memoryFragmentationOverhead    *prometheus.Desc
+	keysExpiringRatio              *prometheus.Desc
+	oliver006DatabaseExpiringRatio *prometheus.Desc
+}
+
+func newDerivedCollector(constLabels prometheus.Labels) SubCollector {
+	return &derivedCollector{
+		keyspaceHitRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "keyspace_hit_ratio"),
+			"Derived: ratio of successful key lookups, keyspace_hits / (keyspace_hits + keyspace_misses).",
+			nil, constLabels,
+		),
+		memoryUtilizationRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "memory_utilization_ratio"),
+			"Derived: used_memory / maxmemory, or used_memory / total_system_memory when maxmemory is not set.",
+			nil, constLabels,
+		),
+		memoryFragmentationOverhead: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "memory_fragmentation_overhead_bytes"),
+			"Derived: memory allocated by the operating system over the used one, used_memory_rss - used_memory.",
+			nil, constLabels,
+		),
+		keysExpiringRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "keys_expiring_ratio"),
+			"Derived: ratio of keys with expiration per Redis database, expires / keys.",
+			[]string{"database"}, constLabels,
+		),
+		oliver006DatabaseExpiringRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "keys_expiring_ratio"),
+			"Derived: ratio of keys with expiration per Redis database, expires / keys.",
+			[]string{"db"}, constLabels,
+		),
+	}
+}
+
+// Describe writes descs of derived metrics, the database label follows the naming scheme like keyspace metrics.
+func (c *derivedCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.keyspaceHitRatio
+	ch <- c.memoryUtilizationRatio
+	ch <- c.memoryFragmentationOverhead
+
+	if naming == Oliver006Naming {
+		ch <- c.oliver006DatabaseExpiringRatio
+	} else {
+		ch <- c.keysExpiringRatio
+	}
+}
+
+// Update returns derived metrics which can be computed from queried fields.
+// Ratios with a zero denominator are left out rather than exposed as NaN.
+func (c *derivedCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	hits, hitsOk := parseField(scrape.Info, "keyspace_hits")
+	misses, missesOk := parseField(scrape.Info, "keyspace_misses")
+	if hitsOk && missesOk && hits+misses > 0 {
+		ch <- prometheus.MustNewConstMetric(c.keyspaceHitRatio, prometheus.GaugeValue, hits/(hits+misses))
+	}
+
+	used, usedOk := parseField(scrape.Info, "used_memory")
+	if usedOk {
+		// Instances without maxmemory are limited by the memory of the host.
+		limit, ok := parseField(scrape.Info, "maxmemory")
+		if !ok || limit == 0 {
+			limit, ok = parseField(scrape.Info, "total_system_memory")
+		}
+		if ok && limit > 0 {
+			ch <- prometheus.MustNewConstMetric(c.memoryUtilizationRatio, prometheus.GaugeValue, used/limit)
+		}
+	}
+
+	if rss, ok := parseField(scrape.Info, "used_memory_rss"); ok && usedOk {
+		ch <- prometheus.MustNewConstMetric(c.memoryFragmentationOverhead, prometheus.GaugeValue, rss-used)
+	}
+
+	// Databases missing from the keyspace section have no keys, so their ratio is left out.
+	for _, database := range scrape.Databases {
+		v := scrape.Keyspace[database]
+		keys, keysOk := parseField(v, "keys")
+		expires, expiresOk := parseField(v, "expires")
+		if !keysOk || !expiresOk || keys == 0 {
+			continue
+		}
+
+		if scrape.Naming == Oliver006Naming {
+			db := "db" + strconv.Itoa(database)
+			ch <- prometheus.MustNewConstMetric(c.oliver006DatabaseExpiringRatio, prometheus.GaugeValue, expires/keys, db)
+		} else {
+			db := strconv.Itoa(database)
+			ch <- prometheus.MustNewConstMetric(c.keysExpiringRatio, prometheus.GaugeValue, expires/keys, db)
+		}
+	}
+
+	return nil
+}
+
+// Returns the numerical value of the field, false when the field is missing or not a number.
+func parseField(fields map[string]string, field string) (float64, bool) {
+	value, ok := fields[field]
+	if !ok {
+		return 0, false
+	}
+
+	val, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return val, true
+}
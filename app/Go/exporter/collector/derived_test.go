@@ -0,0 +1,72 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/collector"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redis derived metrics", func() {
+	var fixture *collectorFixture
+
+	// Returns the response body of a scrape with INFO sections.
+	scrape := func(stats string, memory string) string {
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Stats").Return(redis.NewStringResult(stats, nil))
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Memory").Return(redis.NewStringResult(memory, nil))
+
+		return fixture.scrape()
+	}
+
+	BeforeEach(func() {
+		fixture = newCollectorFixture(context.Background(), []string{"Stats", "Memory"}, []int{0, 1})
+		Expect(fixture.metricsCollector.SetCollectors([]string{"derived"})).To(Succeed())
+
+		keyspace := "# Keyspace\ndb0:keys=10,expires=4,avg_ttl=0\ndb1:keys=0,expires=0,avg_ttl=0\n"
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult(keyspace, nil))
+	})
+
+	It("Returns ratios and overheads computed from fields", func() {
+		body := scrape(
+			"# Stats\nkeyspace_hits:75\nkeyspace_misses:25\n",
+			"# Memory\nused_memory:1000\nused_memory_rss:1500\nmaxmemory:4000\ntotal_system_memory:100000\n",
+		)
+
+		Expect(body).To(ContainSubstring("redis_keyspace_hit_ratio 0.75"))
+		Expect(body).To(ContainSubstring("redis_memory_utilization_ratio 0.25"))
+		Expect(body).To(ContainSubstring("redis_memory_fragmentation_overhead_bytes 500"))
+		Expect(body).To(ContainSubstring(`redis_keys_expiring_ratio{database="0"} 0.4`))
+	})
+
+	It("Uses system memory when maxmemory is not set", func() {
+		body := scrape("# Stats\n", "# Memory\nused_memory:1000\nmaxmemory:0\ntotal_system_memory:10000\n")
+
+		Expect(body).To(ContainSubstring("redis_memory_utilization_ratio 0.1"))
+	})
+
+	table.DescribeTable("Leaves out metrics which can't be computed",
+		func(stats string, memory string, missing string) {
+			Expect(scrape(stats, memory)).NotTo(ContainSubstring(missing + " "))
+		},
+		table.Entry("No lookups", "# Stats\nkeyspace_hits:0\nkeyspace_misses:0\n", "# Memory\n", "redis_keyspace_hit_ratio"),
+		table.Entry("No memory limit", "# Stats\n", "# Memory\nused_memory:1000\nmaxmemory:0\n", "redis_memory_utilization_ratio"),
+		table.Entry("No RSS", "# Stats\n", "# Memory\nused_memory:1000\n", "redis_memory_fragmentation_overhead_bytes"),
+		table.Entry("Empty database", "# Stats\n", "# Memory\n", `redis_keys_expiring_ratio{database="1"}`),
+	)
+
+	It("Computes ratios of databases by their indexes", func() {
+		fixture.metricsCollector.UpdateSettings(fixture.mockClient, []string{"Stats", "Memory"}, []int{1, 5})
+
+		body := scrape("# Stats\n", "# Memory\n")
+		Expect(body).NotTo(ContainSubstring("redis_keys_expiring_ratio{"))
+	})
+
+	It("Labels databases like oliver006/redis_exporter with its naming scheme", func() {
+		fixture.metricsCollector.SetNamingScheme(collector.Oliver006Naming)
+
+		Expect(scrape("# Stats\n", "# Memory\n")).To(ContainSubstring(`redis_keys_expiring_ratio{db="db0"} 0.4`))
+	})
+})
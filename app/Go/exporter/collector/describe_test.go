@@ -0,0 +1,136 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// Pedantic registries reject metrics with descs which were not described, so every metric with a described desc must match it.
+var _ = Describe("Redis collector descriptors", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockClient       *mocks.MockRedisClient
+		metricsCollector *collector.MetricsCollector
+	)
+
+	// Returns metric families gathered from a pedantic registry with collectors of a single scrape.
+	gather := func() []*dto.MetricFamily {
+		registry := prometheus.NewPedanticRegistry()
+		Expect(metricsCollector.RegisterScrape(context.Background(), registry)).To(Succeed())
+
+		families, err := registry.Gather()
+		Expect(err).To(BeNil())
+
+		return families
+	}
+
+	// Returns names of gathered metric families.
+	names := func(families []*dto.MetricFamily) []string {
+		var names []string
+		for _, family := range families {
+			names = append(names, family.GetName())
+		}
+
+		return names
+	}
+
+	// Sets up responses of a single scrape.
+	expectScrape := func() {
+		responses := map[string]string{
+			"Server":       "# Server\nredis_version:6.0.9\nos:Linux\nuptime_in_seconds:3600\n",
+			"Clients":      "# Clients\nconnected_clients:3\n",
+			"Memory":       "# Memory\nused_memory:862632\nused_memory_future_field:7\n",
+			"Replication":  "# Replication\nrole:slave\nmaster_link_status:up\nslave_repl_offset:10\n",
+			"Commandstats": "# Commandstats\ncmdstat_get:calls=40,usec=120,usec_per_call=3.00\n",
+			"Keyspace":     "# Keyspace\ndb0:keys=10,expires=2,avg_ttl=1500\n",
+		}
+		for section, response := range responses {
+			mockClient.EXPECT().Info(gomock.Any(), section).Return(redis.NewStringResult(response, nil))
+		}
+
+		mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "LEN").Return(redis.NewCmdResult(int64(0), nil))
+		mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "GET", 1).Return(redis.NewCmdResult([]interface{}{}, nil))
+		mockClient.EXPECT().Do(gomock.Any(), "CLUSTER", "INFO").Return(redis.NewCmdResult("cluster_state:ok\r\ncluster_known_nodes:6\r\ncluster_future_field:1\r\n", nil))
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mocks.NewMockRedisClient(mockCtrl)
+
+		sections := []string{"Server", "Clients", "Memory", "Replication", "Commandstats"}
+		metricsCollector = collector.NewMetricsCollector(context.Background(), mockClient, sections, []int{0})
+		metricsCollector.EnableSelfMetrics()
+		Expect(metricsCollector.SetCollectors([]string{"info", "keyspace", "commandstats", "replication", "slowlog", "cluster"})).To(Succeed())
+		Expect(metricsCollector.SetConstLabels(map[string]string{"env": "prod"})).To(Succeed())
+
+		expectScrape()
+	})
+
+	table.DescribeTable("Collects metrics of known and unknown fields with pedantic checks",
+		func(scheme collector.NamingScheme, expected []string) {
+			metricsCollector.SetNamingScheme(scheme)
+
+			Expect(names(gather())).To(ContainElements(expected))
+		},
+		table.Entry("Default", collector.DefaultNaming, []string{
+			"redis_info_used_memory", "redis_info_used_memory_future_field", "redis_info_non_numerical", "redis_cluster_known_nodes",
+			"redis_cluster_future_field", "redis_master_link_up", "redis_commands_total", "redis_slowlog_length", "redis_exporter_collector_duration_seconds",
+		}),
+		table.Entry("oliver006/redis_exporter", collector.Oliver006Naming, []string{
+			"redis_memory_used_bytes", "redis_instance_info", "redis_db_keys", "redis_uptime_in_seconds", "redis_cluster_state",
+		}),
+	)
+
+	It("Collects metrics of the collector registered directly with a pedantic registry before and after a reload", func() {
+		registry := prometheus.NewPedanticRegistry()
+		Expect(metricsCollector.Register(registry)).To(Succeed())
+
+		// Both collectors of the registration share a single Redis query, so every response is expected once per gather.
+		families, err := registry.Gather()
+		Expect(err).To(BeNil())
+		Expect(names(families)).To(ContainElements("redis_up", "redis_info_used_memory", "redis_info_used_memory_future_field", "redis_info_non_numerical"))
+
+		// Reload rebuilds descs with other constant labels and names after the collector was registered.
+		Expect(metricsCollector.SetConstLabels(map[string]string{"env": "staging"})).To(Succeed())
+		metricsCollector.SetNamingScheme(collector.Oliver006Naming)
+		expectScrape()
+
+		families, err = registry.Gather()
+		Expect(err).To(BeNil())
+		Expect(names(families)).To(ContainElements("redis_up", "redis_memory_used_bytes", "redis_instance_info"))
+		for _, family := range families {
+			if family.GetName() == "redis_up" {
+				Expect(family.GetMetric()[0].GetLabel()[0].GetValue()).To(Equal("staging"))
+			}
+		}
+	})
+
+	It("Describes metrics of enabled collectors only", func() {
+		Expect(metricsCollector.SetCollectors([]string{"info"})).To(Succeed())
+
+		descs := make(chan *prometheus.Desc)
+		go func() {
+			metricsCollector.Describe(descs)
+			close(descs)
+		}()
+
+		var described []string
+		for desc := range descs {
+			described = append(described, desc.String())
+		}
+
+		Expect(described).To(ContainElement(ContainSubstring(`"redis_info_used_memory"`)))
+		Expect(described).NotTo(ContainElement(ContainSubstring(`"redis_keys_per_database_count"`)))
+
+		// Unused expectations of other collectors are satisfied by a scrape.
+		gather()
+	})
+})
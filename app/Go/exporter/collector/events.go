@@ -0,0 +1,196 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+	"time"
+)
+
+func init() {
+	registerCollector("events", "Restarts, role changes, replication ID and memory configuration changes observed between scrapes.", false, newEventsCollector)
+}
+
+// trackedEvent is a change of INFO fields between scrapes of the same target.
+type trackedEvent struct {
+	name string
+	// Counter and timestamp metric names.
+	total     string
+	timestamp string
+	// Help of the counter and the timestamp metrics.
+	totalHelp     string
+	timestampHelp string
+	// Fields compared with their previous values, a change of any of them is an event.
+	fields []string
+}
+
+// Events detected from INFO fields, fields of sections which are not required are not compared.
+var trackedEvents = []trackedEvent{
+	{
+		name: "restart", total: "restarts_observed_total", timestamp: "last_restart_timestamp_seconds",
+		totalHelp:     "Number of observed restarts of Redis, run_id changes.",
+		timestampHelp: "Unix time of the last observed restart computed from the uptime.",
+		fields:        []string{"run_id"},
+	},
+	{
+		name: "role_change", total: "role_changes_total", timestamp: "last_role_change_timestamp_seconds",
+		totalHelp:     "Number of observed role changes of Redis, e.g. failovers.",
+		timestampHelp: "Unix time of the scrape which observed the last role change.",
+		fields:        []string{"role"},
+	},
+	{
+		name: "replication_id_change", total: "replication_id_changes_total", timestamp: "last_replication_id_change_timestamp_seconds",
+		totalHelp:     "Number of observed master_replid changes.",
+		timestampHelp: "Unix time of the scrape which observed the last master_replid change.",
+		fields:        []string{"master_replid"},
+	},
+	{
+		name: "memory_config_change", total: "memory_config_changes_total", timestamp: "last_memory_config_change_timestamp_seconds",
+		totalHelp:     "Number of observed maxmemory or maxmemory_policy changes.",
+		timestampHelp: "Unix time of the scrape which observed the last maxmemory or maxmemory_policy change.",
+		fields:        []string{"maxmemory", "maxmemory_policy"},
+	},
+}
+
+// eventStatus holds the number of observed events of a kind and the time of the last one.
+type eventStatus struct {
+	count float64
+	// Zero before the first event.
+	last time.Time
+}
+
+// eventTracker remembers tracked fields of the previous snapshot of the target and counts their changes.
+// It lives as long as the collector of the target, so events are counted since the exporter start.
+type eventTracker struct {
+	mu       sync.Mutex
+	previous map[string]string
+	// Statuses of events whose fields were seen at least once by event names.
+	statuses map[string]eventStatus
+}
+
+func newEventTracker() *eventTracker {
+	return &eventTracker{
+		previous: make(map[string]string),
+		statuses: make(map[string]eventStatus),
+	}
+}
+
+// Compares tracked fields of the snapshot with the previous ones and records events.
+// Fields missing from the snapshot, e.g. of failed sections, keep their previous values.
+func (tracker *eventTracker) observe(s *snapshot, logger *zap.Logger) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	for _, event := range trackedEvents {
+		seen := false
+		changed := false
+		for _, field := range event.fields {
+			current, ok := s.generalMetrics[field]
+			if !ok {
+				continue
+			}
+			seen = true
+
+			if previous, ok := tracker.previous[field]; ok && previous != current {
+				changed = true
+				logger.Info("Detected Redis event", zap.String("event", event.name), zap.String("field", field),
+					zap.String("previous", previous), zap.String("current", current))
+			}
+			tracker.previous[field] = current
+		}
+
+		if !seen {
+			continue
+		}
+
+		status := tracker.statuses[event.name]
+		if changed {
+			status.count++
+			status.last = eventTime(event, s)
+		}
+		tracker.statuses[event.name] = status
+	}
+}
+
+// Returns the time of the event detected in the snapshot. Restart time is known from the uptime,
+// other events happened between the previous scrape and this one, so the scrape time is used.
+func eventTime(event trackedEvent, s *snapshot) time.Time {
+	if event.name == "restart" {
+		if uptime, err := strconv.ParseInt(s.generalMetrics["uptime_in_seconds"], 10, 64); err == nil {
+			return s.time.Add(-time.Duration(uptime) * time.Second)
+		}
+	}
+
+	return s.time
+}
+
+// Returns a copy of event statuses by event names.
+func (tracker *eventTracker) status() map[string]eventStatus {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	statuses := make(map[string]eventStatus, len(tracker.statuses))
+	for name, status := range tracker.statuses {
+		statuses[name] = status
+	}
+
+	return statuses
+}
+
+// eventDescs are descs of the counter and the last event timestamp of an event.
+type eventDescs struct {
+	total     *prometheus.Desc
+	timestamp *prometheus.Desc
+}
+
+// eventsCollector returns counters and last timestamps of events observed by the collector.
+type eventsCollector struct {
+	descs map[string]eventDescs
+}
+
+func newEventsCollector(constLabels prometheus.Labels) SubCollector {
+	c := &eventsCollector{descs: make(map[string]eventDescs)}
+	for _, event := range trackedEvents {
+		c.descs[event.name] = eventDescs{
+			total: prometheus.NewDesc(
+				prometheus.BuildFQName(namespace, "", event.total),
+				event.totalHelp,
+				nil, constLabels,
+			),
+			timestamp: prometheus.NewDesc(
+				prometheus.BuildFQName(namespace, "", event.timestamp),
+				event.timestampHelp,
+				nil, constLabels,
+			),
+		}
+	}
+
+	return c
+}
+
+// Describe writes descs of event metrics, they are named the same in all naming schemes.
+func (c *eventsCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	for _, event := range trackedEvents {
+		ch <- c.descs[event.name].total
+		ch <- c.descs[event.name].timestamp
+	}
+}
+
+// Update returns counters of events whose fields were queried, timestamps are returned once an event happened.
+func (c *eventsCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	for _, event := range trackedEvents {
+		status, ok := scrape.events[event.name]
+		if !ok {
+			continue
+		}
+
+		descs := c.descs[event.name]
+		ch <- prometheus.MustNewConstMetric(descs.total, prometheus.CounterValue, status.count)
+		if !status.last.IsZero() {
+			ch <- prometheus.MustNewConstMetric(descs.timestamp, prometheus.GaugeValue, float64(status.last.UnixNano())/1e9)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"time"
+)
+
+var _ = Describe("Redis events", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockClient       *mocks.MockRedisClient
+		metricsCollector *collector.MetricsCollector
+	)
+
+	// Returns values of gathered metrics by names after a scrape of Redis with the server and replication state.
+	scrape := func(runID string, uptime int, role string, replID string) map[string]float64 {
+		server := fmt.Sprintf("# Server\nrun_id:%s\nuptime_in_seconds:%d\n", runID, uptime)
+		replication := fmt.Sprintf("# Replication\nrole:%s\nmaster_replid:%s\n", role, replID)
+		mockClient.EXPECT().Info(gomock.Any(), "Server").Return(redis.NewStringResult(server, nil))
+		mockClient.EXPECT().Info(gomock.Any(), "Replication").Return(redis.NewStringResult(replication, nil))
+		mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\n", nil))
+
+		registry := prometheus.NewPedanticRegistry()
+		Expect(metricsCollector.RegisterScrape(context.Background(), registry)).To(Succeed())
+
+		families, err := registry.Gather()
+		Expect(err).To(BeNil())
+
+		values := make(map[string]float64)
+		for _, family := range families {
+			metric := family.GetMetric()[0]
+			if family.GetType() == dto.MetricType_COUNTER {
+				values[family.GetName()] = metric.GetCounter().GetValue()
+			} else {
+				values[family.GetName()] = metric.GetGauge().GetValue()
+			}
+		}
+
+		return values
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mocks.NewMockRedisClient(mockCtrl)
+
+		metricsCollector = collector.NewMetricsCollector(context.Background(), mockClient, []string{"Server", "Replication"}, []int{})
+		Expect(metricsCollector.SetCollectors([]string{"events"})).To(Succeed())
+	})
+
+	It("Counts events from the first scrape without timestamps", func() {
+		values := scrape("a", 100, "master", "r1")
+
+		Expect(values).To(HaveKeyWithValue("redis_restarts_observed_total", 0.0))
+		Expect(values).To(HaveKeyWithValue("redis_role_changes_total", 0.0))
+		Expect(values).To(HaveKeyWithValue("redis_replication_id_changes_total", 0.0))
+		Expect(values).NotTo(HaveKey("redis_last_restart_timestamp_seconds"))
+
+		// Memory section is not required.
+		Expect(values).NotTo(HaveKey("redis_memory_config_changes_total"))
+	})
+
+	It("Detects restarts with their time computed from the uptime", func() {
+		scrape("a", 100, "master", "r1")
+
+		before := time.Now()
+		values := scrape("b", 30, "master", "r1")
+
+		Expect(values).To(HaveKeyWithValue("redis_restarts_observed_total", 1.0))
+		Expect(values).To(HaveKeyWithValue("redis_replication_id_changes_total", 0.0))
+		Expect(values["redis_last_restart_timestamp_seconds"]).To(BeNumerically("~", float64(before.Unix()-30), 1))
+	})
+
+	It("Detects failovers and keeps counting them", func() {
+		scrape("a", 100, "slave", "r1")
+
+		before := time.Now()
+		scrape("a", 110, "master", "r2")
+		values := scrape("a", 120, "slave", "r2")
+
+		Expect(values).To(HaveKeyWithValue("redis_role_changes_total", 2.0))
+		Expect(values).To(HaveKeyWithValue("redis_replication_id_changes_total", 1.0))
+		Expect(values).To(HaveKeyWithValue("redis_restarts_observed_total", 0.0))
+		Expect(values["redis_last_role_change_timestamp_seconds"]).To(BeNumerically(">=", float64(before.Unix())))
+		Expect(values["redis_last_replication_id_change_timestamp_seconds"]).To(BeNumerically("<=", values["redis_last_role_change_timestamp_seconds"]))
+	})
+
+	It("Forgets the previous state when the target changes", func() {
+		metricsCollector.SetTarget("redis-1:6379")
+		scrape("a", 100, "master", "r1")
+
+		metricsCollector.SetTarget("redis-2:6379")
+		Expect(scrape("b", 100, "master", "r1")).To(HaveKeyWithValue("redis_restarts_observed_total", 0.0))
+	})
+})
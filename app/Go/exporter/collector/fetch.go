@@ -0,0 +1,400 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"exporter/exporter/client"
+	"exporter/exporter/tracing"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/label"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Number of sections queried concurrently when it's not configured.
+const defaultWorkers = 4
+
+// ScrapeOptions declares how sections and commands of sub-collectors are queried during a scrape.
+type ScrapeOptions struct {
+	// Maximum number of sections and sub-collectors queried concurrently, the default is used when not set.
+	Workers int
+	// Timeout of every section query and sub-collector without its own timeout, only the scrape deadline applies when not set.
+	SectionTimeout time.Duration
+	// Sections and commands of sub-collectors are queried in a single pipeline instead of concurrent queries,
+	// so a scrape takes one round trip. The pipeline is limited by the default section timeout, timeouts of single
+	// sections and sub-collectors don't apply.
+	Pipeline bool
+}
+
+// SetScrapeOptions replaces the concurrency, the default timeout and pipelining of section queries.
+func (collector *MetricsCollector) SetScrapeOptions(options ScrapeOptions) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.scrapeOptions = options
+}
+
+// sectionErrors holds errors of failed section queries by section names.
+type sectionErrors map[string]error
+
+func (errs sectionErrors) Error() string {
+	messages := []string{}
+	for section, err := range errs {
+		messages = append(messages, fmt.Sprintf("%s: %v", section, err))
+	}
+	sort.Strings(messages)
+
+	return "failed to query INFO sections: " + strings.Join(messages, "; ")
+}
+
+// sectionResult holds data returned by a single section query.
+type sectionResult struct {
+	info     map[string]string
+	keyspace map[int]map[string]string
+	err      error
+}
+
+// Queries required sections, the keyspace section and commands of the fetchers for the databases either concurrently or in a single pipeline.
+// Failed sections are counted in the error metric and left out, so data of the rest is still returned.
+// Nil snapshot is returned only when every section failed.
+func (collector *MetricsCollector) fetch(ctx context.Context, logger *zap.Logger, requiredMetrics []string, databases []int, fetchers []namedFetcher, options ScrapeOptions, redisClient client.RedisClient) (*snapshot, error) {
+	// Keyspace section is always queried as it provides per-database metrics.
+	sections := []string{}
+	for _, section := range requiredMetrics {
+		if !strings.EqualFold(section, keyspaceSection) {
+			sections = append(sections, section)
+		}
+	}
+	sections = append(sections, keyspaceSection)
+
+	var results []sectionResult
+	var fetched []fetchResult
+	if options.Pipeline {
+		results, fetched = collector.fetchPipelined(ctx, logger, sections, databases, fetchers, options.SectionTimeout, redisClient)
+	} else {
+		results, fetched = collector.fetchConcurrently(ctx, logger, sections, databases, fetchers, options, redisClient)
+	}
+
+	// Results are merged in the order of required sections, so the outcome doesn't depend on timing.
+	s := &snapshot{generalMetrics: make(map[string]string), fetched: make(map[string]fetchResult)}
+	for i, f := range fetchers {
+		s.fetched[f.name] = fetched[i]
+	}
+
+	errs := sectionErrors{}
+	for i, section := range sections {
+		result := results[i]
+		if result.err != nil {
+			collector.countSectionError(section, errorReason(result.err))
+			errs[section] = result.err
+			continue
+		}
+
+		for k, v := range result.info {
+			s.generalMetrics[k] = v
+		}
+		if result.keyspace != nil {
+			s.keyspaceMetrics = result.keyspace
+		}
+	}
+
+	if len(errs) == len(sections) {
+		return nil, errs
+	}
+	if len(errs) > 0 {
+		return s, errs
+	}
+
+	return s, nil
+}
+
+// Queries sections and fetchers with a bounded number of workers, results are returned in the order of sections and fetchers.
+func (collector *MetricsCollector) fetchConcurrently(ctx context.Context, logger *zap.Logger, sections []string, databases []int, fetchers []namedFetcher, options ScrapeOptions, redisClient client.RedisClient) ([]sectionResult, []fetchResult) {
+	total := len(sections) + len(fetchers)
+	workers := options.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	if workers > total {
+		workers = total
+	}
+
+	results := make([]sectionResult, len(sections))
+	fetched := make([]fetchResult, len(fetchers))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// Jobs after sections are fetchers.
+			for i := range jobs {
+				if i < len(sections) {
+					results[i] = collector.fetchSection(ctx, logger, sections[i], options.SectionTimeout, redisClient)
+				} else {
+					fetched[i-len(sections)] = collector.fetchCollector(ctx, logger, fetchers[i-len(sections)], databases, options.SectionTimeout, redisClient)
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < total; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results, fetched
+}
+
+// Queries a single section, its cached result is returned while it's fresh.
+func (collector *MetricsCollector) fetchSection(ctx context.Context, logger *zap.Logger, section string, timeout time.Duration, redisClient client.RedisClient) sectionResult {
+	o, cached, ok := collector.sections.lookup(section)
+	if ok {
+		logger.Debug("Served cached INFO section", zap.String("section", section))
+		return cached
+	}
+
+	// Timeout of the section takes precedence over the default one.
+	if o.Timeout > 0 {
+		timeout = o.Timeout
+	}
+
+	sectionCtx, cancel := withOptionalTimeout(ctx, timeout)
+	defer cancel()
+
+	sectionCtx, span := tracing.Start(sectionCtx, "INFO "+section, trace.WithAttributes(label.String("redis.section", section)))
+
+	start := time.Now()
+	data := redisClient.Info(sectionCtx, section)
+	duration := time.Since(start)
+	collector.observeSection(section, duration)
+
+	result := parseSection(section, data)
+	tracing.End(span, result.err)
+	logger.Debug("Queried INFO section", zap.String("section", section), zap.Duration("duration", duration), zap.Error(result.err))
+	collector.sections.store(section, result)
+
+	return result
+}
+
+// Fetches data of a single sub-collector, its cached result is returned while it's fresh.
+func (collector *MetricsCollector) fetchCollector(ctx context.Context, logger *zap.Logger, f namedFetcher, databases []int, timeout time.Duration, redisClient client.RedisClient) fetchResult {
+	o, cached, ok := collector.sections.lookupFetched(f.name)
+	if ok {
+		logger.Debug("Served cached collector data", zap.String("collector", f.name))
+		return cached
+	}
+
+	// Timeout of the sub-collector takes precedence over the default one.
+	if o.Timeout > 0 {
+		timeout = o.Timeout
+	}
+
+	result := fetchCommands(ctx, logger, f, databases, timeout, redisClient)
+	collector.sections.storeFetched(f.name, result)
+
+	return result
+}
+
+// Sends commands of the fetcher one by one, the first failed command stops the fetch.
+func fetchCommands(ctx context.Context, logger *zap.Logger, f namedFetcher, databases []int, timeout time.Duration, redisClient client.RedisClient) fetchResult {
+	fetchCtx, cancel := withOptionalTimeout(ctx, timeout)
+	defer cancel()
+
+	fetchCtx, span := tracing.Start(fetchCtx, "collector "+f.name, trace.WithAttributes(label.String("redis.collector", f.name)))
+
+	start := time.Now()
+	cmds := []*redis.Cmd{}
+	for _, args := range f.fetcher.Commands(databases) {
+		cmd := redisClient.Do(fetchCtx, args...)
+		cmds = append(cmds, cmd)
+		if cmd.Err() != nil {
+			break
+		}
+	}
+
+	result := parseFetched(f.fetcher, cmds)
+	result.duration = time.Since(start)
+	tracing.End(span, result.err)
+	logger.Debug("Fetched collector data", zap.String("collector", f.name), zap.Duration("duration", result.duration), zap.Error(result.err))
+
+	return result
+}
+
+// Queries sections which are not cached and commands of fetchers in a single pipeline,
+// results are returned in the order of sections and fetchers.
+func (collector *MetricsCollector) fetchPipelined(ctx context.Context, logger *zap.Logger, sections []string, databases []int, fetchers []namedFetcher, timeout time.Duration, redisClient client.RedisClient) ([]sectionResult, []fetchResult) {
+	results := make([]sectionResult, len(sections))
+	fetched := make([]fetchResult, len(fetchers))
+
+	// Indexes of sections which have to be queried.
+	due := []int{}
+	for i, section := range sections {
+		_, cached, ok := collector.sections.lookup(section)
+		if ok {
+			logger.Debug("Served cached INFO section", zap.String("section", section))
+			results[i] = cached
+			continue
+		}
+		due = append(due, i)
+	}
+
+	// Commands of fetchers which are not cached follow sections in the pipeline.
+	commands := make([][][]interface{}, len(fetchers))
+	names := make([]string, len(due))
+	for n, i := range due {
+		names[n] = sections[i]
+	}
+	dueFetchers := []int{}
+	fetcherNames := []string{}
+	queued := len(due)
+	for i, f := range fetchers {
+		_, cached, ok := collector.sections.lookupFetched(f.name)
+		if ok {
+			logger.Debug("Served cached collector data", zap.String("collector", f.name))
+			fetched[i] = cached
+			continue
+		}
+
+		dueFetchers = append(dueFetchers, i)
+		commands[i] = f.fetcher.Commands(databases)
+		fetcherNames = append(fetcherNames, f.name)
+		queued += len(commands[i])
+	}
+
+	if queued == 0 {
+		return results, fetched
+	}
+
+	pipelineCtx, cancel := withOptionalTimeout(ctx, timeout)
+	defer cancel()
+
+	pipelineCtx, span := tracing.Start(pipelineCtx, "INFO pipeline", trace.WithAttributes(
+		label.String("redis.sections", strings.Join(names, ",")),
+		label.String("redis.collectors", strings.Join(fetcherNames, ",")),
+	))
+
+	// Errors of single commands are kept in their results, the returned error is one of them.
+	start := time.Now()
+	cmds, err := redisClient.Pipelined(pipelineCtx, func(pipe redis.Pipeliner) error {
+		for _, i := range due {
+			pipe.Info(pipelineCtx, sections[i])
+		}
+		for _, i := range dueFetchers {
+			for _, args := range commands[i] {
+				pipe.Do(pipelineCtx, args...)
+			}
+		}
+		return nil
+	})
+	duration := time.Since(start)
+	tracing.End(span, err)
+
+	for n, i := range due {
+		collector.observeSection(sections[i], duration)
+
+		cmd, ok := infoCommandAt(cmds, n)
+		if ok {
+			results[i] = parseSection(sections[i], cmd)
+			collector.sections.store(sections[i], results[i])
+		} else {
+			results[i] = sectionResult{err: pipelineError(err)}
+		}
+
+		logger.Debug("Queried INFO section in pipeline", zap.String("section", sections[i]), zap.Duration("duration", duration), zap.Error(results[i].err))
+	}
+
+	n := len(due)
+	for _, i := range dueFetchers {
+		f := fetchers[i]
+		fetched[i] = fetchResult{err: pipelineError(err)}
+
+		fetcherCmds := make([]*redis.Cmd, 0, len(commands[i]))
+		for range commands[i] {
+			cmd, ok := commandAt(cmds, n)
+			n++
+			if ok {
+				fetcherCmds = append(fetcherCmds, cmd)
+			}
+		}
+		if len(fetcherCmds) == len(commands[i]) {
+			fetched[i] = parseFetched(f.fetcher, fetcherCmds)
+			collector.sections.storeFetched(f.name, fetched[i])
+		}
+		fetched[i].duration = duration
+
+		logger.Debug("Fetched collector data in pipeline", zap.String("collector", f.name), zap.Duration("duration", duration), zap.Error(fetched[i].err))
+	}
+
+	return results, fetched
+}
+
+// Returns INFO command result from pipeline results by its index.
+func infoCommandAt(cmds []redis.Cmder, n int) (*redis.StringCmd, bool) {
+	if n >= len(cmds) {
+		return nil, false
+	}
+
+	cmd, ok := cmds[n].(*redis.StringCmd)
+	return cmd, ok
+}
+
+// Returns the result of a command of a fetcher from pipeline results by its index.
+func commandAt(cmds []redis.Cmder, n int) (*redis.Cmd, bool) {
+	if n >= len(cmds) {
+		return nil, false
+	}
+
+	cmd, ok := cmds[n].(*redis.Cmd)
+	return cmd, ok
+}
+
+// Returns the error for commands which have no result because the pipeline failed as a whole.
+func pipelineError(err error) error {
+	if err == nil {
+		return errors.New("no result returned from pipeline")
+	}
+
+	return err
+}
+
+// Returns the reason label of the section error metric.
+func errorReason(err error) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return "timeout"
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+
+	return "error"
+}
+
+// Counts the failed query of the section.
+func (collector *MetricsCollector) countSectionError(section string, reason string) {
+	collector.mu.RLock()
+	sectionErrors := collector.sectionErrors
+	collector.mu.RUnlock()
+
+	sectionErrors.WithLabelValues(section, reason).Inc()
+}
+
+// Records the duration of the section query.
+func (collector *MetricsCollector) observeSection(section string, duration time.Duration) {
+	collector.mu.RLock()
+	sectionDuration := collector.sectionDuration
+	collector.mu.RUnlock()
+
+	sectionDuration.WithLabelValues(section).Observe(duration.Seconds())
+}
@@ -0,0 +1,111 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/collector"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Both fetch modes send the same commands, so a pipelined scrape returns the same metrics as a concurrent one.
+var _ = Describe("Redis collector fetch modes", func() {
+	var (
+		mu       sync.Mutex
+		commands []string
+	)
+
+	// Returns the reply of Redis to the command.
+	reply := func(args []interface{}) interface{} {
+		switch args[0] {
+		case "info":
+			return map[string]string{
+				"Clients":  "# Clients\nconnected_clients:3\n",
+				"Keyspace": "# Keyspace\ndb0:keys=10,expires=2,avg_ttl=1500\ndb1:keys=1,expires=0,avg_ttl=0\n",
+			}[args[1].(string)]
+		case "CONFIG":
+			return []interface{}{args[2], map[interface{}]string{"maxmemory": "1048576", "maxclients": "10000"}[args[2]]}
+		case "EVAL":
+			// DBSIZE script gets the database as its only argument.
+			return map[interface{}]int64{0: 10, 1: 1}[args[3]]
+		}
+
+		return nil
+	}
+
+	// Records the command sent to Redis.
+	record := func(args []interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		// Scripts are told apart by their arguments.
+		if args[0] == "EVAL" {
+			args = append([]interface{}{"EVAL", "script"}, args[2:]...)
+		}
+		commands = append(commands, strings.ToLower(strings.TrimSpace(fmt.Sprintln(args...))))
+	}
+
+	// Returns the response of a scrape of the collector and sorted commands sent to Redis.
+	scrape := func(pipeline bool) (string, []string) {
+		commands = nil
+
+		fixture := newCollectorFixture(context.Background(), []string{"Clients"}, []int{0, 1})
+		Expect(fixture.metricsCollector.SetCollectors([]string{"info", "keyspace", "config", "dbsize"})).To(Succeed())
+		fixture.metricsCollector.SetScrapeOptions(collector.ScrapeOptions{Pipeline: pipeline})
+
+		if pipeline {
+			fixture.mockClient.EXPECT().Pipelined(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(redis.Pipeliner) error) ([]redis.Cmder, error) {
+				// Commands are queued in a pipeline of a client which can't connect, so only their arguments are kept.
+				pipe := redis.NewClient(&redis.Options{Addr: "localhost:1", MaxRetries: -1}).Pipeline()
+				Expect(fn(pipe)).To(Succeed())
+				queued, _ := pipe.Exec(ctx)
+
+				results := make([]redis.Cmder, 0, len(queued))
+				for _, cmd := range queued {
+					record(cmd.Args())
+					if _, ok := cmd.(*redis.StringCmd); ok {
+						results = append(results, redis.NewStringResult(reply(cmd.Args()).(string), nil))
+					} else {
+						results = append(results, redis.NewCmdResult(reply(cmd.Args()), nil))
+					}
+				}
+
+				return results, nil
+			})
+		} else {
+			fixture.mockClient.EXPECT().Info(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, section ...string) *redis.StringCmd {
+				args := []interface{}{"info", section[0]}
+				record(args)
+				return redis.NewStringResult(reply(args).(string), nil)
+			}).AnyTimes()
+			fixture.mockClient.EXPECT().Do(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, args ...interface{}) *redis.Cmd {
+				record(args)
+				return redis.NewCmdResult(reply(args), nil)
+			}).AnyTimes()
+		}
+
+		body := fixture.scrape()
+		sort.Strings(commands)
+
+		return body, commands
+	}
+
+	It("Sends CONFIG GET and DBSIZE of every database in the pipeline like concurrent queries", func() {
+		concurrentBody, concurrentCommands := scrape(false)
+		pipelinedBody, pipelinedCommands := scrape(true)
+
+		Expect(pipelinedCommands).To(Equal(concurrentCommands))
+		Expect(pipelinedCommands).To(ContainElements("config get maxmemory", "config get maxclients", "eval script 0 0", "eval script 0 1"))
+
+		Expect(pipelinedBody).To(Equal(concurrentBody))
+		Expect(pipelinedBody).To(ContainSubstring("redis_config_maxmemory 1.048576e+06"))
+		Expect(pipelinedBody).To(ContainSubstring("redis_config_maxclients 10000"))
+		Expect(pipelinedBody).To(ContainSubstring(`redis_dbsize_keys{database="0"} 10`))
+		Expect(pipelinedBody).To(ContainSubstring(`redis_dbsize_keys{database="1"} 1`))
+	})
+})
@@ -0,0 +1,198 @@
+package collector
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Header with the scrape timeout Prometheus sets for every scrape request.
+const scrapeTimeoutHeader = "X-Prometheus-Scrape-Timeout-Seconds"
+
+// Part of the scrape timeout left for the exporter to write the response after Redis calls are cancelled.
+const scrapeTimeoutOffset = 500 * time.Millisecond
+
+// sharedScrape collects metrics of a single scrape once, relabels them and splits them between checked and unchecked collectors.
+type sharedScrape struct {
+	collector *MetricsCollector
+	ctx       context.Context
+	described map[string]bool
+	once      sync.Once
+	checked   []prometheus.Metric
+	unchecked []prometheus.Metric
+}
+
+// Collects metrics of the scrape on the first call, later calls wait for it.
+func (scrape *sharedScrape) run() {
+	scrape.once.Do(func() {
+		metrics := make(chan prometheus.Metric)
+		done := make(chan struct{})
+
+		var collected []prometheus.Metric
+		go func() {
+			defer close(done)
+			for m := range metrics {
+				collected = append(collected, m)
+			}
+		}()
+
+		// Metrics are split by their descs below, so the scrape sends all of them to a single channel.
+		scrape.collector.collect(scrape.ctx, metrics, metrics)
+		close(metrics)
+		<-done
+
+		relabeled, err := scrape.collector.currentRelabeler().Metrics(collected)
+		if err != nil {
+			scrape.collector.logger().Error("Failed to relabel metrics", zap.Error(err))
+		}
+
+		// Metrics with descs which were not described at registration are sent by the unchecked collector.
+		// These are metrics of fields which are not known up front, relabeled metrics and metrics with descs
+		// rebuilt by a reload, e.g. with other constant labels.
+		for _, m := range relabeled {
+			if scrape.described[m.Desc().String()] {
+				scrape.checked = append(scrape.checked, m)
+			} else {
+				scrape.unchecked = append(scrape.unchecked, m)
+			}
+		}
+	})
+}
+
+// scrapePair holds the state shared by the checked and the unchecked collector registered together.
+type scrapePair struct {
+	collector *MetricsCollector
+	ctx       context.Context
+	descs     []*prometheus.Desc
+	described map[string]bool
+
+	mu             sync.Mutex
+	pending        *sharedScrape
+	pendingChecked bool
+}
+
+// Returns the scrape for the checked or the unchecked collector. The scrape started by one collector of the pair
+// is taken by the other one, so both collectors gathered by a registry get metrics of the same Redis query.
+func (pair *scrapePair) next(checked bool) *sharedScrape {
+	pair.mu.Lock()
+	defer pair.mu.Unlock()
+
+	if pair.pending != nil && pair.pendingChecked != checked {
+		scrape := pair.pending
+		pair.pending = nil
+		return scrape
+	}
+
+	pair.pending = &sharedScrape{collector: pair.collector, ctx: pair.ctx, described: pair.described}
+	pair.pendingChecked = checked
+
+	return pair.pending
+}
+
+// checkedScrapeCollector collects metrics of the scrape with descs described at registration.
+type checkedScrapeCollector struct {
+	*scrapePair
+}
+
+// Describe writes descriptors of the collector taken at registration, so the registry can unregister the collector after a reload.
+func (c checkedScrapeCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+}
+
+// Collect returns metrics of the scrape with described descs.
+func (c checkedScrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := c.next(true)
+	scrape.run()
+	for _, m := range scrape.checked {
+		ch <- m
+	}
+}
+
+// uncheckedScrapeCollector collects metrics of the scrape with descs which were not described.
+type uncheckedScrapeCollector struct {
+	*scrapePair
+}
+
+// Describe writes no descriptors, so the collector is registered as unchecked.
+func (c uncheckedScrapeCollector) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect returns metrics of the scrape with descs which were not described.
+func (c uncheckedScrapeCollector) Collect(ch chan<- prometheus.Metric) {
+	scrape := c.next(false)
+	scrape.run()
+	for _, m := range scrape.unchecked {
+		ch <- m
+	}
+}
+
+// Register registers collectors of the metrics with the registerer, Redis is queried with the collector context.
+// Metrics with descs described at registration are collected by a checked collector and the rest by an unchecked one,
+// so the collector passes checks of pedantic registries also after reloads which change the descs.
+func (collector *MetricsCollector) Register(registerer prometheus.Registerer) error {
+	return collector.RegisterScrape(collector.ctx, registerer)
+}
+
+// RegisterScrape registers collectors of the metrics like Register, Redis is queried with the given context.
+// Both collectors gathered by a registry share a single Redis query.
+func (collector *MetricsCollector) RegisterScrape(ctx context.Context, registerer prometheus.Registerer) error {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		collector.Describe(descs)
+		close(descs)
+	}()
+
+	pair := &scrapePair{collector: collector, ctx: ctx, described: make(map[string]bool)}
+	for desc := range descs {
+		pair.descs = append(pair.descs, desc)
+		pair.described[desc.String()] = true
+	}
+
+	err := registerer.Register(checkedScrapeCollector{pair})
+	if err != nil {
+		return err
+	}
+
+	return registerer.Register(uncheckedScrapeCollector{pair})
+}
+
+// NewHandler returns HTTP handler which exposes metrics of the collector together with metrics from the gatherer.
+// The collector is scraped with a context derived from the request, the scrape stops waiting for Redis when Prometheus gives up on it.
+func NewHandler(collector *MetricsCollector, gatherer prometheus.Gatherer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := ScrapeContext(r)
+		defer cancel()
+
+		// Registry for a single scrape holds collectors bound to the scrape context.
+		scrapeRegistry := prometheus.NewRegistry()
+		err := collector.RegisterScrape(ctx, scrapeRegistry)
+		if err != nil {
+			http.Error(w, "Failed to register collector: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		promhttp.HandlerFor(prometheus.Gatherers{gatherer, scrapeRegistry}, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+	})
+}
+
+// ScrapeContext returns the request context with the deadline from the Prometheus scrape timeout header.
+// Part of the timeout is left for writing the response, the request context is returned as is without the header.
+func ScrapeContext(r *http.Request) (context.Context, context.CancelFunc) {
+	seconds, err := strconv.ParseFloat(r.Header.Get(scrapeTimeoutHeader), 64)
+	if err != nil || seconds <= 0 {
+		return context.WithCancel(r.Context())
+	}
+
+	timeout := time.Duration(seconds * float64(time.Second))
+	if timeout > scrapeTimeoutOffset {
+		timeout -= scrapeTimeoutOffset
+	}
+
+	return context.WithTimeout(r.Context(), timeout)
+}
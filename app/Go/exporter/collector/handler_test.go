@@ -0,0 +1,134 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"exporter/exporter/config"
+	"exporter/exporter/relabel"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Metrics HTTP handler", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockClient       *mocks.MockRedisClient
+		metricsCollector *collector.MetricsCollector
+		registry         *prometheus.Registry
+		handler          http.Handler
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mocks.NewMockRedisClient(mockCtrl)
+
+		metricsCollector = collector.NewMetricsCollector(context.Background(), mockClient, []string{"Clients"}, []int{1})
+
+		// Metrics from the gatherer are exposed together with the collector metrics.
+		registry = prometheus.NewRegistry()
+		up := prometheus.NewGauge(prometheus.GaugeOpts{Name: "exporter_static_metric", Help: "Static metric."})
+		up.Set(1)
+		registry.MustRegister(up)
+
+		handler = collector.NewHandler(metricsCollector, registry)
+	})
+
+	When("Prometheus sets the scrape timeout", func() {
+		BeforeEach(func() {
+			// Redis calls must get the context with the deadline from the scrape timeout header.
+			expectDeadline := func(ctx context.Context) {
+				deadline, ok := ctx.Deadline()
+				Expect(ok).To(BeTrue())
+				Expect(time.Until(deadline)).To(BeNumerically("~", 2500*time.Millisecond, 500*time.Millisecond))
+			}
+
+			mockClient.EXPECT().Info(gomock.Any(), "Clients").DoAndReturn(func(ctx context.Context, section ...string) *redis.StringCmd {
+				expectDeadline(ctx)
+				return redis.NewStringResult("# Clients\nconnected_clients:3\n", nil)
+			})
+			mockClient.EXPECT().Info(gomock.Any(), "Keyspace").DoAndReturn(func(ctx context.Context, section ...string) *redis.StringCmd {
+				expectDeadline(ctx)
+				return redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)
+			})
+		})
+
+		It("Queries Redis with the scrape context and returns all metrics", func() {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "3")
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Body.String()).To(ContainSubstring("exporter_static_metric 1"))
+			Expect(rr.Body.String()).To(ContainSubstring("redis_clients_connected_total 3"))
+		})
+	})
+
+	When("Relabeling rules would rename metrics to metrics of the gatherer", func() {
+		BeforeEach(func() {
+			buildInfo := prometheus.NewGauge(prometheus.GaugeOpts{Name: "redis_exporter_build_info", Help: "Build information."})
+			buildInfo.Set(1)
+			registry.MustRegister(buildInfo)
+
+			// Captured groups pass validation of the name, the final name is one of the exporter metrics.
+			relabeler, err := relabel.New(config.MetricFilters{}, []config.RelabelRule{
+				{Metric: "(redis)_clients_connected_total", Action: config.RelabelRename, Name: "${1}_exporter_build_info"},
+			})
+			Expect(err).To(BeNil())
+			metricsCollector.SetRelabeler(relabeler)
+
+			mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil))
+			mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil))
+		})
+
+		It("Keeps names of the metrics, so they don't clash with metrics of the gatherer", func() {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+			Expect(rr.Code).To(Equal(http.StatusOK), rr.Body.String())
+			Expect(rr.Body.String()).To(ContainSubstring("redis_clients_connected_total 3"))
+			Expect(rr.Body.String()).To(ContainSubstring("redis_exporter_build_info 1"))
+		})
+	})
+
+	Describe("Building the scrape context", func() {
+		It("Uses the request context without the scrape timeout header", func() {
+			ctx, cancel := collector.ScrapeContext(httptest.NewRequest("GET", "/metrics", nil))
+			defer cancel()
+
+			_, ok := ctx.Deadline()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Ignores invalid scrape timeout", func() {
+			req := httptest.NewRequest("GET", "/metrics", nil)
+			req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "soon")
+
+			ctx, cancel := collector.ScrapeContext(req)
+			defer cancel()
+
+			_, ok := ctx.Deadline()
+			Expect(ok).To(BeFalse())
+		})
+
+		It("Is cancelled together with the request", func() {
+			requestCtx, cancelRequest := context.WithCancel(context.Background())
+			req := httptest.NewRequest("GET", "/metrics", nil).WithContext(requestCtx)
+			req.Header.Set("X-Prometheus-Scrape-Timeout-Seconds", "10")
+
+			ctx, cancel := collector.ScrapeContext(req)
+			defer cancel()
+
+			cancelRequest()
+			Eventually(ctx.Done()).Should(BeClosed())
+		})
+	})
+})
@@ -0,0 +1,193 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"sort"
+	"strconv"
+)
+
+func init() {
+	registerCollector("info", "Fields of queried INFO sections.", true, newInfoCollector)
+}
+
+// oliver006Field is an INFO field with its oliver006/redis_exporter metric.
+type oliver006Field struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+}
+
+// infoCollector returns fields of queried INFO sections.
+type infoCollector struct {
+	constLabels           prometheus.Labels
+	clientsConnectedTotal *prometheus.Desc
+
+	// Descs of catalog fields by field names.
+	fields map[string]*prometheus.Desc
+
+	// Descs of oliver006/redis_exporter naming scheme.
+	oliver006Fields map[string]oliver006Field
+	instanceInfo    *prometheus.Desc
+}
+
+func newInfoCollector(constLabels prometheus.Labels) SubCollector {
+	c := &infoCollector{
+		constLabels: constLabels,
+		clientsConnectedTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "clients_connected_total"),
+			"Total number of clients connected to Redis.",
+			nil, constLabels,
+		),
+		fields:          make(map[string]*prometheus.Desc),
+		oliver006Fields: make(map[string]oliver006Field),
+		instanceInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "instance_info"),
+			"Information about the Redis instance",
+			oliver006InstanceLabels, constLabels,
+		),
+	}
+
+	for _, fields := range infoCatalog {
+		for _, field := range fields {
+			c.fields[field] = c.fieldDesc(field)
+		}
+	}
+
+	for field, name := range oliver006Gauges {
+		c.oliver006Fields[field] = oliver006Field{desc: c.oliver006Desc(name), valueType: prometheus.GaugeValue}
+	}
+	for field, name := range oliver006Counters {
+		c.oliver006Fields[field] = oliver006Field{desc: c.oliver006Desc(name), valueType: prometheus.CounterValue}
+	}
+
+	return c
+}
+
+// Returns the desc of the INFO field metric.
+func (c *infoCollector) fieldDesc(field string) *prometheus.Desc {
+	return prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "info", field),
+		"Data gathered from Redis INFO.",
+		nil, c.constLabels,
+	)
+}
+
+// Returns the desc of the oliver006/redis_exporter metric with the name.
+func (c *infoCollector) oliver006Desc(name string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", name), name+" metric", nil, c.constLabels)
+}
+
+// Describe writes descs of catalog fields and custom metrics of the naming scheme.
+func (c *infoCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	if naming == Oliver006Naming {
+		for _, field := range c.oliver006Fields {
+			ch <- field.desc
+		}
+		ch <- c.instanceInfo
+		return
+	}
+
+	for _, desc := range c.fields {
+		ch <- desc
+	}
+	ch <- c.clientsConnectedTotal
+}
+
+// Update returns INFO fields with names of the naming scheme.
+func (c *infoCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	if scrape.Naming == Oliver006Naming {
+		c.updateOliver006(scrape, ch)
+		return nil
+	}
+
+	// Non-numerical values cannot be set as values for Prometheus metrics.
+	// Store this exceptional data and return it later as labels for metric.
+	numerical := make(map[string]string)
+	nonNumerical := make(map[string]string)
+	for k, v := range scrape.Info {
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			numerical[k] = v
+		} else {
+			nonNumerical[k] = v
+		}
+	}
+
+	// Field names are sanitized, so fields of modules and forks with any characters can be exposed.
+	// Field can't take the name of the non-numerical metric.
+	for _, field := range sanitizeFields(scrape, numerical, map[string]bool{"non_numerical": true}) {
+		val, _ := strconv.ParseFloat(field.value, 64)
+
+		// Fields which are not in the catalog can't be described up front.
+		if desc, ok := c.fields[field.field]; ok {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, val)
+		} else {
+			scrape.Unchecked <- prometheus.MustNewConstMetric(c.fieldDesc(field.name), prometheus.GaugeValue, val)
+		}
+	}
+
+	// Constant labels take precedence over fields with the same names.
+	taken := make(map[string]bool, len(c.constLabels))
+	for k := range c.constLabels {
+		taken[k] = true
+	}
+
+	stringMetricsKeys := []string{}
+	stringMetricsValues := []string{}
+	for _, field := range sanitizeFields(scrape, nonNumerical, taken) {
+		stringMetricsKeys = append(stringMetricsKeys, field.name)
+		stringMetricsValues = append(stringMetricsValues, sanitizeLabelValue(field.value))
+	}
+
+	// Return all non-numeric metrics as labels, label names depend on queried sections.
+	stringMetric := prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "info", "non_numerical"),
+		"Non-numerical data gathered from Redis INFO.",
+		stringMetricsKeys, c.constLabels,
+	)
+
+	scrape.Unchecked <- prometheus.MustNewConstMetric(stringMetric, prometheus.GaugeValue, 1, stringMetricsValues...)
+
+	// Return required common custom metric, it's missing when Clients section is not required or failed.
+	if _, ok := scrape.Info["connected_clients"]; ok {
+		emitField(ch, scrape.Logger, c.clientsConnectedTotal, "Clients", scrape.Info, "connected_clients")
+	}
+
+	return nil
+}
+
+// Returns INFO fields with oliver006/redis_exporter names and labels.
+func (c *infoCollector) updateOliver006(scrape *Scrape, ch chan<- prometheus.Metric) {
+	// Fields are emitted in a stable order, so logs of fields which can't be read don't depend on map iteration.
+	fields := make([]string, 0, len(scrape.Info))
+	for k := range scrape.Info {
+		fields = append(fields, k)
+	}
+	sort.Strings(fields)
+
+	for _, k := range fields {
+		field, ok := c.oliver006Fields[k]
+		if !ok {
+			continue
+		}
+
+		val, err := parseOliver006Value(scrape.Info[k])
+		if err != nil {
+			scrape.Logger.Debug("Failed to read metric", zap.String("field", k), zap.Error(err))
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(field.desc, field.valueType, val)
+	}
+
+	// Instance information is returned when any of its fields was queried.
+	labels := make([]string, len(oliver006InstanceLabels))
+	found := false
+	for i, k := range oliver006InstanceLabels {
+		v, ok := scrape.Info[k]
+		labels[i] = sanitizeLabelValue(v)
+		found = found || ok
+	}
+	if found {
+		ch <- prometheus.MustNewConstMetric(c.instanceInfo, prometheus.GaugeValue, 1, labels...)
+	}
+}
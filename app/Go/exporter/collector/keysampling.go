@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+)
+
+func init() {
+	registerCollector("key-sampling", "Types and memory usage of keys sampled with RANDOMKEY in configured databases.", false, newKeySamplingCollector)
+}
+
+// Number of keys sampled in every configured database.
+const keySampleSize = 100
+
+// Samples random keys of databases passed after the sample size and returns rows of database, type,
+// number of sampled keys and their memory usage. The database selected by the script doesn't change the one of the connection.
+// Memory usage is 0 when MEMORY USAGE is not available, e.g. on Redis before 4.0.
+const keySamplingScript = `
+local result = {}
+for n = 2, #ARGV do
+	local db = ARGV[n]
+	redis.call('SELECT', db)
+	local types = {}
+	for i = 1, tonumber(ARGV[1]) do
+		local key = redis.call('RANDOMKEY')
+		if not key then
+			break
+		end
+		local keyType = redis.call('TYPE', key)['ok']
+		if keyType ~= 'none' then
+			local bytes = redis.pcall('MEMORY', 'USAGE', key)
+			if type(bytes) ~= 'number' then
+				bytes = 0
+			end
+			local sampled = types[keyType] or {0, 0}
+			sampled[1] = sampled[1] + 1
+			sampled[2] = sampled[2] + bytes
+			types[keyType] = sampled
+		end
+	end
+	for keyType, sampled in pairs(types) do
+		table.insert(result, {tonumber(db), keyType, sampled[1], sampled[2]})
+	end
+end
+return result
+`
+
+// keySample holds the number and memory usage of sampled keys of a single type in a database.
+type keySample struct {
+	database int
+	keyType  string
+	count    int64
+	bytes    int64
+}
+
+// keySamplingCollector returns types and memory usage of keys sampled by a Lua script.
+type keySamplingCollector struct {
+	sampledKeys  *prometheus.Desc
+	sampledBytes *prometheus.Desc
+}
+
+func newKeySamplingCollector(constLabels prometheus.Labels) SubCollector {
+	return &keySamplingCollector{
+		sampledKeys: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "sampled_keys"),
+			"Number of keys of the type among "+strconv.Itoa(keySampleSize)+" keys sampled per Redis database.",
+			[]string{"database", "type"}, constLabels,
+		),
+		sampledBytes: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "sampled_keys_memory_bytes"),
+			"Memory used by sampled keys of the type per Redis database, as reported by MEMORY USAGE.",
+			[]string{"database", "type"}, constLabels,
+		),
+	}
+}
+
+// Describe writes descs of key sampling metrics, they are named the same in all naming schemes.
+func (c *keySamplingCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.sampledKeys
+	ch <- c.sampledBytes
+}
+
+// Commands returns the script which samples keys of the databases.
+func (c *keySamplingCollector) Commands(databases []int) [][]interface{} {
+	return [][]interface{}{scriptCommand(keySamplingScript, keySampleSize, databases)}
+}
+
+// Parse returns sampled keys by databases and types.
+func (c *keySamplingCollector) Parse(cmds []*redis.Cmd) (interface{}, error) {
+	rows, err := replyRows(cmds[0], 4)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]keySample, 0, len(rows))
+	for _, row := range rows {
+		database, databaseOk := row[0].(int64)
+		keyType, typeOk := row[1].(string)
+		count, countOk := row[2].(int64)
+		bytes, bytesOk := row[3].(int64)
+		if !databaseOk || !typeOk || !countOk || !bytesOk {
+			return nil, errors.New("unexpected key sampling reply")
+		}
+
+		samples = append(samples, keySample{database: int(database), keyType: keyType, count: count, bytes: bytes})
+	}
+
+	return samples, nil
+}
+
+// Update returns sampled keys of the databases.
+func (c *keySamplingCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	samples, ok := scrape.Data.([]keySample)
+	if !ok {
+		return nil
+	}
+
+	for _, sample := range samples {
+		db := strconv.Itoa(sample.database)
+		ch <- prometheus.MustNewConstMetric(c.sampledKeys, prometheus.GaugeValue, float64(sample.count), db, sample.keyType)
+		ch <- prometheus.MustNewConstMetric(c.sampledBytes, prometheus.GaugeValue, float64(sample.bytes), db, sample.keyType)
+	}
+
+	return nil
+}
+
+// Returns EVAL command of the script without keys, the argument is followed by the databases.
+func scriptCommand(script string, arg int, databases []int) []interface{} {
+	cmd := []interface{}{"EVAL", script, 0, arg}
+	for _, database := range databases {
+		cmd = append(cmd, database)
+	}
+
+	return cmd
+}
+
+// Returns rows of the array reply, every row must be an array with at least the given number of values.
+func replyRows(cmd *redis.Cmd, columns int) ([][]interface{}, error) {
+	reply, err := cmd.Result()
+	if err != nil {
+		return nil, err
+	}
+
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, errors.New("unexpected array reply")
+	}
+
+	rows := make([][]interface{}, 0, len(items))
+	for _, item := range items {
+		row, ok := item.([]interface{})
+		if !ok || len(row) < columns {
+			return nil, errors.New("unexpected array reply")
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
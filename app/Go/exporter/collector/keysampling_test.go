@@ -0,0 +1,45 @@
+package collector_test
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redis key sampling", func() {
+	var fixture *collectorFixture
+
+	BeforeEach(func() {
+		fixture = newCollectorFixture(context.Background(), []string{"Clients"}, []int{0, 1})
+		Expect(fixture.metricsCollector.SetCollectors([]string{"key-sampling"})).To(Succeed())
+		fixture.metricsCollector.EnableSelfMetrics()
+
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil))
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb0:keys=10,expires=0,avg_ttl=0\n", nil))
+	})
+
+	It("Returns types and memory usage of sampled keys of configured databases", func() {
+		fixture.mockClient.EXPECT().Do(gomock.Any(), "EVAL", gomock.Any(), 0, 100, 0, 1).Return(redis.NewCmdResult([]interface{}{
+			[]interface{}{int64(0), "string", int64(7), int64(392)},
+			[]interface{}{int64(0), "hash", int64(3), int64(1024)},
+		}, nil))
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_sampled_keys{database="0",type="string"} 7`))
+		Expect(body).To(ContainSubstring(`redis_sampled_keys_memory_bytes{database="0",type="hash"} 1024`))
+		Expect(body).NotTo(ContainSubstring(`database="1"`))
+		Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="key-sampling"} 1`))
+	})
+
+	It("Marks the collector as failed when the script fails", func() {
+		fixture.mockClient.EXPECT().Do(gomock.Any(), "EVAL", gomock.Any(), 0, 100, 0, 1).Return(redis.NewCmdResult(nil, errors.New("NOPERM")))
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring("redis_up 1"))
+		Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="key-sampling"} 0`))
+		Expect(body).NotTo(ContainSubstring("redis_sampled_keys"))
+	})
+})
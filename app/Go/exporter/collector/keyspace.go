@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"strconv"
+)
+
+func init() {
+	registerCollector("keyspace", "Number of keys, expiring keys and average TTL of configured databases.", true, newKeyspaceCollector)
+}
+
+// keyspaceCollector returns keyspace metrics of configured databases.
+type keyspaceCollector struct {
+	keysPerDatabaseCount *prometheus.Desc
+	expiringKeysCount    *prometheus.Desc
+	averageKeyTTLSeconds *prometheus.Desc
+
+	// Descs of oliver006/redis_exporter naming scheme.
+	databaseKeys         *prometheus.Desc
+	databaseKeysExpiring *prometheus.Desc
+	databaseAverageTTL   *prometheus.Desc
+}
+
+func newKeyspaceCollector(constLabels prometheus.Labels) SubCollector {
+	return &keyspaceCollector{
+		keysPerDatabaseCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "keys_per_database_count"),
+			"Number of keys per Redis database.",
+			[]string{"database"}, constLabels,
+		),
+		expiringKeysCount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "expiring_keys_count"),
+			"Number of keys per Redis database.",
+			[]string{"database"}, constLabels,
+		),
+		averageKeyTTLSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "average_key_ttl_seconds"),
+			"Average key TTL in seconds.",
+			[]string{"database"}, constLabels,
+		),
+		databaseKeys: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "db_keys"),
+			"Total number of keys by DB",
+			[]string{"db"}, constLabels,
+		),
+		databaseKeysExpiring: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "db_keys_expiring"),
+			"Total number of expiring keys by DB",
+			[]string{"db"}, constLabels,
+		),
+		databaseAverageTTL: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "db_avg_ttl_seconds"),
+			"Avg TTL in seconds",
+			[]string{"db"}, constLabels,
+		),
+	}
+}
+
+// Describe writes descs of keyspace metrics of the naming scheme.
+func (c *keyspaceCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	if naming == Oliver006Naming {
+		ch <- c.databaseKeys
+		ch <- c.databaseKeysExpiring
+		ch <- c.databaseAverageTTL
+		return
+	}
+
+	ch <- c.keysPerDatabaseCount
+	ch <- c.expiringKeysCount
+	ch <- c.averageKeyTTLSeconds
+}
+
+// Fields of databases without keys, Redis leaves them out of the keyspace section.
+var emptyDatabase = map[string]string{"keys": "0", "expires": "0", "avg_ttl": "0"}
+
+// Update returns metrics for all configured databases with names of the naming scheme.
+// Configured databases missing from the keyspace section have no keys, so they are returned with zero values.
+func (c *keyspaceCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	// Keyspace section failed, there is nothing to tell about databases.
+	if scrape.Keyspace == nil {
+		return nil
+	}
+
+	for _, database := range scrape.Databases {
+		v, ok := scrape.Keyspace[database]
+		if !ok {
+			v = emptyDatabase
+		}
+
+		if scrape.Naming == Oliver006Naming {
+			c.updateOliver006(scrape, ch, database, v)
+			continue
+		}
+
+		db := strconv.Itoa(database)
+		emitField(ch, scrape.Logger, c.keysPerDatabaseCount, keyspaceSection, v, "keys", db)
+		emitField(ch, scrape.Logger, c.expiringKeysCount, keyspaceSection, v, "expires", db)
+		emitField(ch, scrape.Logger, c.averageKeyTTLSeconds, keyspaceSection, v, "avg_ttl", db)
+	}
+
+	return nil
+}
+
+// Returns keyspace metrics of the database with oliver006/redis_exporter names and labels.
+func (c *keyspaceCollector) updateOliver006(scrape *Scrape, ch chan<- prometheus.Metric, database int, metrics map[string]string) {
+	db := "db" + strconv.Itoa(database)
+	emitField(ch, scrape.Logger, c.databaseKeys, keyspaceSection, metrics, "keys", db)
+	emitField(ch, scrape.Logger, c.databaseKeysExpiring, keyspaceSection, metrics, "expires", db)
+
+	// TTL is reported by Redis in milliseconds.
+	ttl, err := strconv.ParseFloat(metrics["avg_ttl"], 64)
+	if err != nil {
+		scrape.Logger.Debug("Failed to read metric", zap.String("section", keyspaceSection), zap.String("field", "avg_ttl"), zap.Error(err))
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.databaseAverageTTL, prometheus.GaugeValue, ttl/1000, db)
+}
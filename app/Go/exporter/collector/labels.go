@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"reflect"
+)
+
+// Names of variable labels of the collector metrics, constant labels with these names would clash with them.
+var reservedLabels = append([]string{"database", "section", "reason", "state", "collector", "db", "cmd", "slave_ip", "slave_port", "slave_state", "type", "stream", "group", "le", "quantile"}, oliver006InstanceLabels...)
+
+// SetConstLabels replaces labels added to every metric of the collector, e.g. env, cluster or alias of Redis target.
+// Labels must not clash with variable labels of the collector metrics. Non-numerical INFO fields with the names
+// of constant labels are left out of redis_info_non_numerical. Exporter counters and histograms restart when labels change.
+func (collector *MetricsCollector) SetConstLabels(labels map[string]string) error {
+	err := ValidateConstLabels(labels)
+	if err != nil {
+		return err
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	// Empty labels are the same as no labels.
+	if len(labels) == 0 && len(collector.constLabels) == 0 || reflect.DeepEqual(prometheus.Labels(labels), collector.constLabels) {
+		return nil
+	}
+
+	constLabels := make(prometheus.Labels, len(labels))
+	for k, v := range labels {
+		constLabels[k] = v
+	}
+
+	// Names were validated when sub-collectors were enabled.
+	subCollectors, _ := newSubCollectors(collector.collectorNames, constLabels)
+
+	collector.constLabels = constLabels
+	collector.descs = newDescriptors(constLabels)
+	collector.subCollectors = subCollectors
+	collector.sectionErrors = newSectionErrors(constLabels)
+	collector.droppedFields = newDroppedFields(constLabels)
+	collector.sectionDuration = newSectionDuration(constLabels)
+
+	return nil
+}
+
+// ValidateConstLabels checks that constant labels don't clash with variable labels of the collector metrics.
+func ValidateConstLabels(labels map[string]string) error {
+	for _, name := range reservedLabels {
+		if _, ok := labels[name]; ok {
+			return fmt.Errorf("constant label %q clashes with the label of Redis metrics", name)
+		}
+	}
+
+	return nil
+}
+
+// Returns descs of metrics with fixed names.
+func (collector *MetricsCollector) descriptors() *descriptors {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+
+	return collector.descs
+}
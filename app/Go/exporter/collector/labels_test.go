@@ -0,0 +1,60 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/collector"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redis collector constant labels", func() {
+	var fixture *collectorFixture
+
+	BeforeEach(func() {
+		fixture = newCollectorFixture(context.Background(), []string{"Server", "Clients"}, []int{1})
+
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Server").Return(redis.NewStringResult("# Server\nredis_version:6.0.9\nos:Linux\nexecutable:/usr/bin/redis-server\n", nil)).AnyTimes()
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil)).AnyTimes()
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)).AnyTimes()
+	})
+
+	It("Adds labels to every metric", func() {
+		Expect(fixture.metricsCollector.SetConstLabels(map[string]string{"env": "prod", "alias": "cache-1"})).To(Succeed())
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_up{alias="cache-1",env="prod"} 1`))
+		Expect(body).To(ContainSubstring(`redis_clients_connected_total{alias="cache-1",env="prod"} 3`))
+		Expect(body).To(ContainSubstring(`redis_info_connected_clients{alias="cache-1",env="prod"} 3`))
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{alias="cache-1",database="1",env="prod"} 2`))
+		Expect(body).To(ContainSubstring(`redis_info_non_numerical{alias="cache-1",env="prod",executable="/usr/bin/redis-server",os="Linux",redis_version="6.0.9"} 1`))
+	})
+
+	It("Adds labels to metrics of oliver006 naming scheme", func() {
+		fixture.metricsCollector.SetNamingScheme(collector.Oliver006Naming)
+		Expect(fixture.metricsCollector.SetConstLabels(map[string]string{"env": "prod"})).To(Succeed())
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_connected_clients{env="prod"} 3`))
+		Expect(body).To(ContainSubstring(`redis_db_keys{db="db1",env="prod"} 2`))
+	})
+
+	It("Leaves out non-numerical fields with the names of constant labels", func() {
+		Expect(fixture.metricsCollector.SetConstLabels(map[string]string{"executable": "redis"})).To(Succeed())
+
+		Expect(fixture.scrape()).To(ContainSubstring(`redis_info_non_numerical{executable="redis",os="Linux",redis_version="6.0.9"} 1`))
+	})
+
+	It("Removes labels when they are unset", func() {
+		Expect(fixture.metricsCollector.SetConstLabels(map[string]string{"env": "prod"})).To(Succeed())
+		Expect(fixture.metricsCollector.SetConstLabels(nil)).To(Succeed())
+
+		Expect(fixture.scrape()).To(ContainSubstring("redis_up 1"))
+	})
+
+	It("Rejects labels which clash with labels of Redis metrics", func() {
+		Expect(fixture.metricsCollector.SetConstLabels(map[string]string{"database": "main"})).NotTo(Succeed())
+		Expect(fixture.metricsCollector.SetConstLabels(map[string]string{"role": "cache"})).NotTo(Succeed())
+	})
+})
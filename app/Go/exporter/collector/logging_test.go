@@ -0,0 +1,59 @@
+package collector_test
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+var _ = Describe("Redis collector logs", func() {
+	var (
+		fixture *collectorFixture
+		logs    *observer.ObservedLogs
+		restore func()
+	)
+
+	BeforeEach(func() {
+		core, observed := observer.New(zap.DebugLevel)
+		logs = observed
+		restore = zap.ReplaceGlobals(zap.New(core))
+
+		fixture = newCollectorFixture(context.Background(), []string{"Clients"}, []int{1})
+		fixture.metricsCollector.SetTarget("redis:6379")
+	})
+
+	AfterEach(func() {
+		restore()
+	})
+
+	It("Writes a debug entry per section with the target, duration and error", func() {
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("", redis.ErrClosed))
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil))
+
+		fixture.scrape()
+
+		entries := logs.FilterMessage("Queried INFO section").FilterField(zap.String("section", "Clients")).All()
+		Expect(entries).To(HaveLen(1))
+		Expect(entries[0].Level).To(Equal(zap.DebugLevel))
+
+		fields := entries[0].ContextMap()
+		Expect(fields).To(HaveKeyWithValue("target", "redis:6379"))
+		Expect(fields).To(HaveKey("duration"))
+		Expect(fields).To(HaveKeyWithValue("error", redis.ErrClosed.Error()))
+	})
+
+	It("Leaves out fields with non-numerical values instead of panicking", func() {
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil))
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=many,expires=0,avg_ttl=0\n", nil))
+
+		body := fixture.scrape()
+
+		Expect(body).NotTo(ContainSubstring("redis_keys_per_database_count"))
+		Expect(body).To(ContainSubstring(`redis_expiring_keys_count{database="1"} 0`))
+		Expect(logs.FilterMessage("Failed to read metric").FilterField(zap.String("field", "keys")).Len()).To(Equal(1))
+	})
+})
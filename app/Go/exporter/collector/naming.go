@@ -0,0 +1,20 @@
+package collector
+
+// NamingScheme selects names and labels of metrics built from Redis data.
+type NamingScheme string
+
+const (
+	// DefaultNaming names metrics like redis_info_used_memory and redis_keys_per_database_count{database="1"}.
+	DefaultNaming NamingScheme = "default"
+	// Oliver006Naming names metrics like oliver006/redis_exporter does, e.g. redis_memory_used_bytes and redis_db_keys{db="db1"}.
+	Oliver006Naming NamingScheme = "oliver006"
+)
+
+// SetNamingScheme replaces the naming scheme of metrics built from Redis data, unknown schemes fall back to the default one.
+// Metrics of the exporter itself keep their names in every scheme.
+func (collector *MetricsCollector) SetNamingScheme(scheme NamingScheme) {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	collector.naming = scheme
+}
@@ -0,0 +1,72 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	"github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+)
+
+// Every naming scheme exposes the same INFO data, metrics are compared with golden files in testdata.
+var _ = Describe("Redis collector naming schemes", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockClient       *mocks.MockRedisClient
+		metricsCollector *collector.MetricsCollector
+		handler          http.Handler
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mocks.NewMockRedisClient(mockCtrl)
+
+		sections := []string{"Server", "Clients", "Memory", "Persistence", "Stats", "Replication", "CPU", "Commandstats"}
+		metricsCollector = collector.NewMetricsCollector(context.Background(), mockClient, sections, []int{0, 1})
+		Expect(metricsCollector.SetCollectors([]string{"info", "keyspace", "commandstats", "replication"})).To(Succeed())
+
+		r := prometheus.NewRegistry()
+		Expect(metricsCollector.Register(r)).To(Succeed())
+		handler = promhttp.HandlerFor(r, promhttp.HandlerOpts{})
+
+		responses := map[string]string{
+			"Server":       "# Server\nredis_version:6.0.9\nredis_build_id:8ddd9a4bbe1c2ab4\nredis_mode:standalone\nos:Linux 5.4.0-1029-aws x86_64\nprocess_id:1\nrun_id:0b2bf0b8e1eba3e0b1c0ad9e5bd4b5f1b3e5f5a4\ntcp_port:6379\nuptime_in_seconds:3600\n",
+			"Clients":      "# Clients\nconnected_clients:3\nclient_longest_output_list:0\nclient_biggest_input_buf:0\nblocked_clients:0\n",
+			"Memory":       "# Memory\nused_memory:862632\nused_memory_human:842.41K\nused_memory_rss:7655424\nused_memory_peak:945504\ntotal_system_memory:13347020800\nused_memory_lua:37888\nmaxmemory:0\nmaxmemory_policy:noeviction\nmem_fragmentation_ratio:8.87\nmem_allocator:jemalloc-4.0.3\n",
+			"Persistence":  "# Persistence\nloading:0\nrdb_changes_since_last_save:5\nrdb_last_save_time:1607000000\nrdb_last_bgsave_status:ok\naof_enabled:0\naof_last_write_status:err\n",
+			"Stats":        "# Stats\ntotal_connections_received:12\ntotal_commands_processed:345\ninstantaneous_ops_per_sec:2\nexpired_keys:1\nevicted_keys:0\nkeyspace_hits:40\nkeyspace_misses:10\n",
+			"Replication":  "# Replication\nrole:master\nconnected_slaves:0\nmaster_repl_offset:0\n",
+			"CPU":          "# CPU\nused_cpu_sys:1.25\nused_cpu_user:2.5\n",
+			"Commandstats": "# Commandstats\ncmdstat_get:calls=40,usec=120,usec_per_call=3.00\ncmdstat_set:calls=5,usec=2500000,usec_per_call=500000.00\n",
+			"Keyspace":     "# Keyspace\ndb0:keys=10,expires=2,avg_ttl=1500\ndb1:keys=1,expires=0,avg_ttl=0\n",
+		}
+		for section, response := range responses {
+			mockClient.EXPECT().Info(gomock.Any(), section).Return(redis.NewStringResult(response, nil))
+		}
+	})
+
+	table.DescribeTable("Returns metrics named by the scheme",
+		func(scheme collector.NamingScheme) {
+			metricsCollector.SetNamingScheme(scheme)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+			Expect(rr.Code).To(Equal(http.StatusOK))
+
+			golden, err := ioutil.ReadFile(filepath.Join("testdata", string(scheme)+".prom"))
+			Expect(err).To(BeNil())
+			Expect(rr.Body.String()).To(Equal(string(golden)))
+		},
+		table.Entry("Default", collector.DefaultNaming),
+		table.Entry("oliver006/redis_exporter", collector.Oliver006Naming),
+	)
+})
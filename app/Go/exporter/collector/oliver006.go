@@ -0,0 +1,118 @@
+package collector
+
+import (
+	"strconv"
+)
+
+// INFO fields exposed as gauges by their oliver006/redis_exporter names, other fields are left out.
+var oliver006Gauges = map[string]string{
+	// Server
+	"uptime_in_seconds": "uptime_in_seconds",
+
+	// Clients
+	"connected_clients":          "connected_clients",
+	"blocked_clients":            "blocked_clients",
+	"tracking_clients":           "tracking_clients",
+	"client_longest_output_list": "client_longest_output_list",
+	"client_biggest_input_buf":   "client_biggest_input_buf",
+
+	// Memory
+	"used_memory":              "memory_used_bytes",
+	"used_memory_rss":          "memory_used_rss_bytes",
+	"used_memory_peak":         "memory_used_peak_bytes",
+	"used_memory_lua":          "memory_used_lua_bytes",
+	"used_memory_overhead":     "memory_used_overhead_bytes",
+	"used_memory_startup":      "memory_used_startup_bytes",
+	"used_memory_dataset":      "memory_used_dataset_bytes",
+	"used_memory_scripts":      "memory_used_scripts_bytes",
+	"maxmemory":                "memory_max_bytes",
+	"total_system_memory":      "total_system_memory_bytes",
+	"mem_fragmentation_ratio":  "mem_fragmentation_ratio",
+	"mem_fragmentation_bytes":  "mem_fragmentation_bytes",
+	"mem_clients_slaves":       "mem_clients_slaves",
+	"mem_clients_normal":       "mem_clients_normal",
+	"allocator_active":         "allocator_active_bytes",
+	"allocator_allocated":      "allocator_allocated_bytes",
+	"allocator_resident":       "allocator_resident_bytes",
+	"allocator_frag_ratio":     "allocator_frag_ratio",
+	"allocator_frag_bytes":     "allocator_frag_bytes",
+	"allocator_rss_ratio":      "allocator_rss_ratio",
+	"allocator_rss_bytes":      "allocator_rss_bytes",
+	"active_defrag_running":    "active_defrag_running",
+	"lazyfree_pending_objects": "lazyfree_pending_objects",
+
+	// Persistence
+	"loading":                      "loading_dump_file",
+	"rdb_changes_since_last_save":  "rdb_changes_since_last_save",
+	"rdb_bgsave_in_progress":       "rdb_bgsave_in_progress",
+	"rdb_last_save_time":           "rdb_last_save_timestamp_seconds",
+	"rdb_last_bgsave_status":       "rdb_last_bgsave_status",
+	"rdb_last_bgsave_time_sec":     "rdb_last_bgsave_duration_sec",
+	"rdb_current_bgsave_time_sec":  "rdb_current_bgsave_duration_sec",
+	"aof_enabled":                  "aof_enabled",
+	"aof_rewrite_in_progress":      "aof_rewrite_in_progress",
+	"aof_rewrite_scheduled":        "aof_rewrite_scheduled",
+	"aof_last_rewrite_time_sec":    "aof_last_rewrite_duration_sec",
+	"aof_current_rewrite_time_sec": "aof_current_rewrite_duration_sec",
+	"aof_last_bgrewrite_status":    "aof_last_bgrewrite_status",
+	"aof_last_write_status":        "aof_last_write_status",
+
+	// Stats
+	"pubsub_channels":           "pubsub_channels",
+	"pubsub_patterns":           "pubsub_patterns",
+	"latest_fork_usec":          "latest_fork_usec",
+	"instantaneous_ops_per_sec": "instantaneous_ops",
+	"instantaneous_input_kbps":  "instantaneous_input_kbps",
+	"instantaneous_output_kbps": "instantaneous_output_kbps",
+
+	// Replication
+	"connected_slaves":               "connected_slaves",
+	"repl_backlog_size":              "replication_backlog_bytes",
+	"repl_backlog_active":            "repl_backlog_is_active",
+	"master_repl_offset":             "master_repl_offset",
+	"second_repl_offset":             "second_repl_offset",
+	"master_last_io_seconds_ago":     "master_last_io_seconds_ago",
+	"master_link_down_since_seconds": "master_link_down_since_seconds",
+	"master_sync_in_progress":        "master_sync_in_progress",
+
+	// Cluster
+	"cluster_enabled": "cluster_enabled",
+}
+
+// INFO fields exposed as counters by their oliver006/redis_exporter names.
+var oliver006Counters = map[string]string{
+	// Stats
+	"total_connections_received": "connections_received_total",
+	"total_commands_processed":   "commands_processed_total",
+	"rejected_connections":       "rejected_connections_total",
+	"total_net_input_bytes":      "net_input_bytes_total",
+	"total_net_output_bytes":     "net_output_bytes_total",
+	"expired_keys":               "expired_keys_total",
+	"evicted_keys":               "evicted_keys_total",
+	"keyspace_hits":              "keyspace_hits_total",
+	"keyspace_misses":            "keyspace_misses_total",
+	"sync_full":                  "replica_resyncs_full",
+	"sync_partial_ok":            "replica_partial_resync_accepted",
+	"sync_partial_err":           "replica_partial_resync_denied",
+
+	// CPU
+	"used_cpu_sys":           "cpu_sys_seconds_total",
+	"used_cpu_user":          "cpu_user_seconds_total",
+	"used_cpu_sys_children":  "cpu_sys_children_seconds_total",
+	"used_cpu_user_children": "cpu_user_children_seconds_total",
+}
+
+// INFO fields exposed as labels of redis_instance_info.
+var oliver006InstanceLabels = []string{"role", "redis_version", "redis_build_id", "redis_mode", "os", "maxmemory_policy", "tcp_port", "run_id", "process_id"}
+
+// Parses the numerical value of INFO field, ok and err statuses are converted to 1 and 0.
+func parseOliver006Value(value string) (float64, error) {
+	switch value {
+	case "ok":
+		return 1, nil
+	case "err":
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(value, 64)
+}
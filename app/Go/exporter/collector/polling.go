@@ -0,0 +1,85 @@
+package collector
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"time"
+)
+
+// snapshot holds data fetched from Redis during a single scrape.
+type snapshot struct {
+	time            time.Time
+	generalMetrics  map[string]string
+	keyspaceMetrics map[int]map[string]string
+	databases       []int
+	// Results of sub-collectors which query Redis with their own commands by collector names.
+	fetched map[string]fetchResult
+}
+
+// StartPolling switches the collector to background polling mode: Redis is queried with the interval
+// until the context is cancelled and scrapes are served from the last fetched snapshot.
+func (collector *MetricsCollector) StartPolling(ctx context.Context, interval time.Duration) {
+	collector.mu.Lock()
+	collector.polling = true
+	collector.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			collector.poll(ctx, interval)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Fetches a new snapshot, the previous one is kept if none of sections can be queried.
+// Polling must not take longer than the interval, so the interval is used as a timeout.
+func (collector *MetricsCollector) poll(ctx context.Context, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	s, err := collector.scrape(ctx)
+
+	collector.mu.Lock()
+	collector.polledUp = s != nil
+	collector.mu.Unlock()
+
+	if s == nil {
+		collector.logger().Error("Failed to poll Redis, serving the previous snapshot", zap.Error(err))
+		return
+	}
+	if err != nil {
+		collector.logger().Warn("Redis was polled partially", zap.Error(err))
+	}
+
+	collector.mu.Lock()
+	collector.snapshot = s
+	collector.mu.Unlock()
+}
+
+// Returns metrics from the last snapshot with its age, only Redis state is returned before the first snapshot is fetched.
+// Redis state reflects the last poll, while the snapshot may come from an earlier one.
+func (collector *MetricsCollector) collectCached(ch chan<- prometheus.Metric, unchecked chan<- prometheus.Metric) {
+	collector.mu.RLock()
+	s := collector.snapshot
+	polledUp := collector.polledUp
+	collector.mu.RUnlock()
+
+	collector.emitUp(polledUp, ch)
+
+	if s == nil {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(collector.descriptors().snapshotAgeSeconds, prometheus.GaugeValue, time.Since(s.time).Seconds())
+
+	collector.emit(s, ch, unchecked)
+}
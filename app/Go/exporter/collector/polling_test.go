@@ -0,0 +1,137 @@
+package collector_test
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+var _ = Describe("Redis collector scrape modes", func() {
+	var (
+		fixture *collectorFixture
+		ctx     context.Context
+		cancel  context.CancelFunc
+	)
+
+	BeforeEach(func() {
+		ctx, cancel = context.WithCancel(context.Background())
+		fixture = newCollectorFixture(ctx, []string{"Clients"}, []int{1})
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	When("Background polling is enabled", func() {
+		BeforeEach(func() {
+			// Redis is queried once by the poller, scrapes don't query it.
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil)).Times(1)
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)).Times(1)
+
+			fixture.metricsCollector.StartPolling(ctx, time.Hour)
+			Eventually(func() time.Time { return fixture.metricsCollector.LastScrape().Time }).ShouldNot(BeZero())
+		})
+
+		It("Serves scrapes from the snapshot with its age", func() {
+			first := fixture.scrape()
+			second := fixture.scrape()
+
+			Expect(first).To(ContainSubstring("redis_clients_connected_total 3"))
+			Expect(first).To(ContainSubstring("redis_exporter_snapshot_age_seconds"))
+			Expect(second).To(ContainSubstring(`redis_keys_per_database_count{database="1"} 2`))
+		})
+	})
+
+	When("Scrapes run concurrently in synchronous mode", func() {
+		BeforeEach(func() {
+			started := make(chan struct{})
+			release := make(chan struct{})
+
+			// The first query blocks until the second scrape joins it.
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").DoAndReturn(func(ctx context.Context, section ...string) *redis.StringCmd {
+				close(started)
+				<-release
+				return redis.NewStringResult("# Clients\nconnected_clients:3\n", nil)
+			}).Times(1)
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)).Times(1)
+
+			go func() {
+				<-started
+				time.Sleep(100 * time.Millisecond)
+				close(release)
+			}()
+		})
+
+		It("Queries Redis once for all of them", func() {
+			var wg sync.WaitGroup
+			results := make([]string, 2)
+
+			for i := range results {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					results[i] = fixture.scrape()
+				}(i)
+			}
+			wg.Wait()
+
+			Expect(results[0]).To(ContainSubstring("redis_clients_connected_total 3"))
+			Expect(results[1]).To(ContainSubstring("redis_clients_connected_total 3"))
+			Expect(results[0]).NotTo(ContainSubstring("redis_exporter_snapshot_age_seconds"))
+		})
+	})
+
+	When("Scrape which started the shared query is cancelled", func() {
+		var (
+			started chan struct{}
+			release chan struct{}
+		)
+
+		BeforeEach(func() {
+			started = make(chan struct{})
+			release = make(chan struct{})
+
+			// The query outlives the cancelled scrape, its context is not cancelled with it.
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").DoAndReturn(func(ctx context.Context, section ...string) *redis.StringCmd {
+				close(started)
+				<-release
+				Expect(ctx.Err()).To(BeNil())
+
+				return redis.NewStringResult("# Clients\nconnected_clients:3\n", nil)
+			}).Times(1)
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)).Times(1)
+		})
+
+		It("Returns the result of the query to other scrapes", func() {
+			requestCtx, cancelRequest := context.WithCancel(context.Background())
+			first := make(chan string)
+			go func() {
+				defer GinkgoRecover()
+				rr := httptest.NewRecorder()
+				fixture.handler.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil).WithContext(requestCtx))
+				first <- rr.Body.String()
+			}()
+			<-started
+
+			second := make(chan string)
+			go func() {
+				defer GinkgoRecover()
+				second <- fixture.scrape()
+			}()
+
+			cancelRequest()
+			Expect(<-first).To(ContainSubstring("redis_up 0"))
+
+			// The second scrape joins the running query before it's released.
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+			Expect(<-second).To(ContainSubstring("redis_clients_connected_total 3"))
+		})
+	})
+})
@@ -0,0 +1,90 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Replica fields of a master, e.g. slave0:ip=10.0.0.2,port=6379,state=online,offset=100,lag=0.
+var replicaField = regexp.MustCompile(`^slave\d+$`)
+
+func init() {
+	registerCollector("replication", "Link state of a replica and offset and lag of replicas from the Replication INFO section.", false, newReplicationCollector)
+}
+
+// replicationCollector returns replication state when Replication section is queried.
+type replicationCollector struct {
+	masterLinkUp      *prometheus.Desc
+	replicaOffset     *prometheus.Desc
+	replicaLagSeconds *prometheus.Desc
+}
+
+func newReplicationCollector(constLabels prometheus.Labels) SubCollector {
+	return &replicationCollector{
+		masterLinkUp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "master_link_up"),
+			"Whether the link of the replica to its master is up.",
+			nil, constLabels,
+		),
+		replicaOffset: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connected_slave_offset_bytes"),
+			"Replication offset of the connected replica.",
+			[]string{"slave_ip", "slave_port", "slave_state"}, constLabels,
+		),
+		replicaLagSeconds: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "connected_slave_lag_seconds"),
+			"Seconds since the last interaction with the connected replica.",
+			[]string{"slave_ip", "slave_port", "slave_state"}, constLabels,
+		),
+	}
+}
+
+// Describe writes descs of replication metrics, they are named the same in all naming schemes.
+func (c *replicationCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.masterLinkUp
+	ch <- c.replicaOffset
+	ch <- c.replicaLagSeconds
+}
+
+// Update returns the master link state of a replica and offsets and lags of replicas connected to a master.
+func (c *replicationCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	if status, ok := scrape.Info["master_link_status"]; ok {
+		value := 0.0
+		if status == "up" {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.masterLinkUp, prometheus.GaugeValue, value)
+	}
+
+	// Fields are emitted in a stable order, so logs of fields which can't be read don't depend on map iteration.
+	var fields []string
+	for k := range scrape.Info {
+		if replicaField.MatchString(k) {
+			fields = append(fields, k)
+		}
+	}
+	sort.Strings(fields)
+
+	for _, k := range fields {
+		replica := parseCommaSeparated(scrape.Info[k])
+		labelValues := []string{sanitizeLabelValue(replica["ip"]), sanitizeLabelValue(replica["port"]), sanitizeLabelValue(replica["state"])}
+
+		offset, err := strconv.ParseFloat(replica["offset"], 64)
+		if err != nil {
+			scrape.Logger.Debug("Failed to read metric", zap.String("section", "Replication"), zap.String("field", k), zap.Error(err))
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.replicaOffset, prometheus.GaugeValue, offset, labelValues...)
+
+		// Lag is not reported by Redis before 3.0.
+		lag, err := strconv.ParseFloat(replica["lag"], 64)
+		if err == nil {
+			ch <- prometheus.MustNewConstMetric(c.replicaLagSeconds, prometheus.GaugeValue, lag, labelValues...)
+		}
+	}
+
+	return nil
+}
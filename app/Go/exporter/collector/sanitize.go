@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"sort"
+	"strings"
+)
+
+// Reasons of dropped fields.
+const (
+	// Field name is empty or has no valid characters.
+	dropInvalid = "invalid"
+	// Another field or label has the same name after sanitization.
+	dropCollision = "collision"
+)
+
+// Returns the counter of INFO fields left out of metrics by reason with the constant labels.
+func newDroppedFields(constLabels prometheus.Labels) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   namespace,
+		Subsystem:   "exporter",
+		Name:        "dropped_fields_total",
+		Help:        "Number of Redis fields left out of metrics on scrapes by reason, either invalid or collision.",
+		ConstLabels: constLabels,
+	}, []string{"reason"})
+}
+
+// Drop logs the field which is left out of metrics and counts it in the dropped fields metric.
+func (scrape *Scrape) Drop(field string, reason string) {
+	scrape.Logger.Debug("Dropped field", zap.String("field", field), zap.String("reason", reason))
+
+	if scrape.droppedFields != nil {
+		scrape.droppedFields.WithLabelValues(reason).Inc()
+	}
+}
+
+// Returns the name with characters which are not valid in Prometheus metric and label names replaced by underscores.
+// Names starting with a digit get an underscore prefix and leading underscores are collapsed, as double underscore
+// prefix is reserved for internal labels. Empty string is returned for names without any letters or digits.
+func sanitizeName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+
+	sanitized := b.String()
+	if strings.Trim(sanitized, "_") == "" {
+		return ""
+	}
+
+	if strings.HasPrefix(sanitized, "__") {
+		sanitized = "_" + strings.TrimLeft(sanitized, "_")
+	}
+
+	return sanitized
+}
+
+// Returns the value with invalid UTF-8 sequences replaced, so it can be used as a label value.
+func sanitizeLabelValue(value string) string {
+	return strings.ToValidUTF8(value, "�")
+}
+
+// sanitizedField is a field with its sanitized name.
+type sanitizedField struct {
+	field string
+	name  string
+	value string
+}
+
+// Returns fields with sanitized names in the order of original names, fields with names which are invalid
+// or taken are dropped. Fields with valid names are preferred over sanitized ones on collisions.
+func sanitizeFields(scrape *Scrape, fields map[string]string, taken map[string]bool) []sanitizedField {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	used := make(map[string]bool, len(taken)+len(keys))
+	for name := range taken {
+		used[name] = true
+	}
+
+	// Valid names are reserved first, so sanitized names don't push out fields which were exposed before.
+	valid := make(map[string]bool)
+	for _, k := range keys {
+		if sanitizeName(k) == k && !used[k] {
+			valid[k] = true
+			used[k] = true
+		}
+	}
+
+	sanitized := make([]sanitizedField, 0, len(keys))
+	for _, k := range keys {
+		if valid[k] {
+			sanitized = append(sanitized, sanitizedField{field: k, name: k, value: fields[k]})
+			continue
+		}
+
+		name := sanitizeName(k)
+		if name == "" {
+			scrape.Drop(k, dropInvalid)
+			continue
+		}
+		if used[name] {
+			scrape.Drop(k, dropCollision)
+			continue
+		}
+
+		used[name] = true
+		sanitized = append(sanitized, sanitizedField{field: k, name: name, value: fields[k]})
+	}
+
+	return sanitized
+}
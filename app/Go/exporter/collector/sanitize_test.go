@@ -0,0 +1,94 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var _ = Describe("Redis collector sanitization", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockClient       *mocks.MockRedisClient
+		metricsCollector *collector.MetricsCollector
+	)
+
+	// Returns metric families by names gathered from a pedantic registry with collectors of a single scrape.
+	gather := func() map[string]*dto.MetricFamily {
+		registry := prometheus.NewPedanticRegistry()
+		Expect(metricsCollector.RegisterScrape(context.Background(), registry)).To(Succeed())
+
+		families, err := registry.Gather()
+		Expect(err).To(BeNil())
+
+		byName := make(map[string]*dto.MetricFamily)
+		for _, family := range families {
+			byName[family.GetName()] = family
+		}
+
+		return byName
+	}
+
+	// Returns the dropped fields counter value of the reason.
+	dropped := func(families map[string]*dto.MetricFamily, reason string) float64 {
+		family, ok := families["redis_exporter_dropped_fields_total"]
+		Expect(ok).To(BeTrue())
+
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "reason" && label.GetValue() == reason {
+					return metric.GetCounter().GetValue()
+				}
+			}
+		}
+
+		return 0
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mocks.NewMockRedisClient(mockCtrl)
+
+		metricsCollector = collector.NewMetricsCollector(context.Background(), mockClient, []string{"Modules"}, []int{})
+		mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\n", nil))
+		Expect(metricsCollector.SetConstLabels(map[string]string{"env": "prod"})).To(Succeed())
+	})
+
+	It("Sanitizes field names and label values and counts dropped fields", func() {
+		response := "# Modules\nmodule-x.count:5\nmodule_x_count:6\nfoo.bar:baz\nenv:staging\n...:1\nversion:\xff1.0\n"
+		mockClient.EXPECT().Info(gomock.Any(), "Modules").Return(redis.NewStringResult(response, nil))
+
+		families := gather()
+
+		// Valid name is kept and the sanitized one which collides with it is dropped.
+		Expect(families).To(HaveKey("redis_info_module_x_count"))
+		Expect(families["redis_info_module_x_count"].GetMetric()[0].GetGauge().GetValue()).To(Equal(6.0))
+
+		Expect(families).To(HaveKey("redis_info_non_numerical"))
+		labels := make(map[string]string)
+		for _, label := range families["redis_info_non_numerical"].GetMetric()[0].GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		Expect(labels).To(HaveKeyWithValue("foo_bar", "baz"))
+		Expect(labels).To(HaveKeyWithValue("version", "�1.0"))
+
+		// Constant label takes precedence over the field with the same name.
+		Expect(labels).To(HaveKeyWithValue("env", "prod"))
+
+		Expect(dropped(families, "collision")).To(Equal(2.0))
+		Expect(dropped(families, "invalid")).To(Equal(1.0))
+	})
+
+	It("Prefixes field names starting with a digit", func() {
+		response := "# Modules\n1st_field:1\n"
+		mockClient.EXPECT().Info(gomock.Any(), "Modules").Return(redis.NewStringResult(response, nil))
+
+		Expect(gather()).To(HaveKey("redis_info__1st_field"))
+	})
+})
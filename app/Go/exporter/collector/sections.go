@@ -0,0 +1,232 @@
+package collector
+
+import (
+	"context"
+	"exporter/exporter/parser"
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Name of the INFO section with per-database data, it's queried separately from other sections.
+const keyspaceSection = "Keyspace"
+
+// SectionOptions declares how often an INFO section is refreshed and how long its query may take.
+type SectionOptions struct {
+	// Section is queried on every scrape when not set, otherwise the cached result is used until it's older than the interval.
+	Interval time.Duration
+	// Timeout of the section query, the default section timeout applies when not set.
+	Timeout time.Duration
+}
+
+// sectionCache keeps results of sections and sub-collectors with refresh intervals between scrapes.
+type sectionCache struct {
+	mu       sync.Mutex
+	options  map[string]SectionOptions
+	info     map[string]cachedInfo
+	keyspace *cachedKeyspace
+	// Options and results of sub-collectors which query Redis with their own commands by collector names.
+	collectorOptions map[string]SectionOptions
+	fetched          map[string]cachedFetch
+}
+
+type cachedInfo struct {
+	time    time.Time
+	metrics map[string]string
+}
+
+type cachedKeyspace struct {
+	time    time.Time
+	metrics map[int]map[string]string
+}
+
+type cachedFetch struct {
+	time   time.Time
+	result fetchResult
+}
+
+// SetSectionOptions replaces refresh intervals and timeouts of sections, keys are section names in any case.
+// Sections without options are queried on every scrape.
+func (collector *MetricsCollector) SetSectionOptions(options map[string]SectionOptions) {
+	normalized := make(map[string]SectionOptions)
+	for section, o := range options {
+		normalized[strings.ToLower(section)] = o
+	}
+
+	collector.sections.mu.Lock()
+	defer collector.sections.mu.Unlock()
+
+	collector.sections.options = normalized
+}
+
+// SetCollectorOptions replaces refresh intervals and timeouts of sub-collectors which query Redis with their own
+// commands, keys are collector names. Unknown names are rejected and the current options are kept.
+// Options of sub-collectors which don't query Redis have no effect.
+func (collector *MetricsCollector) SetCollectorOptions(options map[string]SectionOptions) error {
+	err := ValidateCollectorOptions(options)
+	if err != nil {
+		return err
+	}
+
+	collector.sections.mu.Lock()
+	defer collector.sections.mu.Unlock()
+
+	collector.sections.collectorOptions = options
+	collector.sections.fetched = nil
+
+	return nil
+}
+
+// ValidateCollectorOptions checks that options are set only for registered sub-collectors.
+func ValidateCollectorOptions(options map[string]SectionOptions) error {
+	for name := range options {
+		if _, ok := collectorRegistry[name]; !ok {
+			return fmt.Errorf("unknown collector %q", name)
+		}
+	}
+
+	return nil
+}
+
+// Drops all cached section and sub-collector results, e.g. when clients change.
+func (cache *sectionCache) reset() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	cache.info = nil
+	cache.keyspace = nil
+	cache.fetched = nil
+}
+
+// Returns options of the section and the cached result of INFO section if it's still fresh.
+func (cache *sectionCache) lookupInfo(section string) (SectionOptions, map[string]string, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	o := cache.options[strings.ToLower(section)]
+	cached, ok := cache.info[strings.ToLower(section)]
+	if o.Interval <= 0 || !ok || time.Since(cached.time) >= o.Interval {
+		return o, nil, false
+	}
+
+	return o, cached.metrics, true
+}
+
+func (cache *sectionCache) storeInfo(section string, metrics map[string]string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.options[strings.ToLower(section)].Interval <= 0 {
+		return
+	}
+
+	if cache.info == nil {
+		cache.info = make(map[string]cachedInfo)
+	}
+	cache.info[strings.ToLower(section)] = cachedInfo{time: time.Now(), metrics: metrics}
+}
+
+// Returns options of the keyspace section and its cached result if it's still fresh.
+func (cache *sectionCache) lookupKeyspace() (SectionOptions, map[int]map[string]string, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	o := cache.options[strings.ToLower(keyspaceSection)]
+	if o.Interval <= 0 || cache.keyspace == nil || time.Since(cache.keyspace.time) >= o.Interval {
+		return o, nil, false
+	}
+
+	return o, cache.keyspace.metrics, true
+}
+
+func (cache *sectionCache) storeKeyspace(metrics map[int]map[string]string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.options[strings.ToLower(keyspaceSection)].Interval <= 0 {
+		return
+	}
+
+	cache.keyspace = &cachedKeyspace{time: time.Now(), metrics: metrics}
+}
+
+// Returns options of the section and its cached result if it's still fresh.
+func (cache *sectionCache) lookup(section string) (SectionOptions, sectionResult, bool) {
+	if strings.EqualFold(section, keyspaceSection) {
+		o, keyspace, ok := cache.lookupKeyspace()
+		return o, sectionResult{keyspace: keyspace}, ok
+	}
+
+	o, info, ok := cache.lookupInfo(section)
+	return o, sectionResult{info: info}, ok
+}
+
+// Caches the successful result of the section if the section has a refresh interval.
+func (cache *sectionCache) store(section string, result sectionResult) {
+	if result.err != nil {
+		return
+	}
+
+	if strings.EqualFold(section, keyspaceSection) {
+		cache.storeKeyspace(result.keyspace)
+		return
+	}
+
+	cache.storeInfo(section, result.info)
+}
+
+// Returns options of the sub-collector and its cached result if it's still fresh.
+// Cached results take no time to fetch, so their duration is zero.
+func (cache *sectionCache) lookupFetched(name string) (SectionOptions, fetchResult, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	o := cache.collectorOptions[name]
+	cached, ok := cache.fetched[name]
+	if o.Interval <= 0 || !ok || time.Since(cached.time) >= o.Interval {
+		return o, fetchResult{}, false
+	}
+
+	return o, fetchResult{data: cached.result.data}, true
+}
+
+// Caches the successful result of the sub-collector if it has a refresh interval.
+func (cache *sectionCache) storeFetched(name string, result fetchResult) {
+	if result.err != nil {
+		return
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	if cache.collectorOptions[name].Interval <= 0 {
+		return
+	}
+
+	if cache.fetched == nil {
+		cache.fetched = make(map[string]cachedFetch)
+	}
+	cache.fetched[name] = cachedFetch{time: time.Now(), result: result}
+}
+
+// Parses the result of INFO command for the section.
+func parseSection(section string, data *redis.StringCmd) sectionResult {
+	if strings.EqualFold(section, keyspaceSection) {
+		keyspace, err := parser.ParseKeyspace(data)
+		return sectionResult{keyspace: keyspace, err: err}
+	}
+
+	info, err := parser.ParseInfoSection(data)
+	return sectionResult{info: info, err: err}
+}
+
+// Returns the context with the timeout if it's set, otherwise the context is returned as is.
+func withOptionalTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
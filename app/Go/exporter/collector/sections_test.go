@@ -0,0 +1,181 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/collector"
+	"exporter/exporter/config"
+	"exporter/exporter/relabel"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Redis collector sections", func() {
+	var fixture *collectorFixture
+
+	BeforeEach(func() {
+		fixture = newCollectorFixture(context.Background(), []string{"Clients", "Memory"}, []int{1})
+	})
+
+	When("Section has a refresh interval", func() {
+		BeforeEach(func() {
+			fixture.metricsCollector.SetSectionOptions(map[string]collector.SectionOptions{
+				"memory": {Interval: time.Hour, Timeout: time.Second},
+			})
+
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil)).Times(2)
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)).Times(2)
+
+			// Section with the interval is queried once with its own timeout.
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Memory").DoAndReturn(func(ctx context.Context, section ...string) *redis.StringCmd {
+				deadline, ok := ctx.Deadline()
+				Expect(ok).To(BeTrue())
+				Expect(time.Until(deadline)).To(BeNumerically("<=", time.Second))
+
+				return redis.NewStringResult("# Memory\nused_memory:1024\n", nil)
+			}).Times(1)
+		})
+
+		It("Serves the cached section result until the interval passes", func() {
+			first := fixture.scrape()
+			second := fixture.scrape()
+
+			Expect(first).To(ContainSubstring("redis_info_used_memory 1024"))
+			Expect(second).To(ContainSubstring("redis_info_used_memory 1024"))
+			Expect(second).To(ContainSubstring("redis_clients_connected_total 3"))
+		})
+	})
+
+	When("Section query is slower than the section timeout", func() {
+		BeforeEach(func() {
+			fixture.metricsCollector.SetScrapeOptions(collector.ScrapeOptions{Workers: 2, SectionTimeout: 50 * time.Millisecond})
+
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil))
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil))
+
+			// Slow section blocks until its timeout expires.
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Memory").DoAndReturn(func(ctx context.Context, section ...string) *redis.StringCmd {
+				<-ctx.Done()
+				return redis.NewStringResult("", ctx.Err())
+			})
+		})
+
+		It("Returns the rest of sections and counts the timeout", func() {
+			body := fixture.scrape()
+
+			Expect(body).To(ContainSubstring("redis_clients_connected_total 3"))
+			Expect(body).To(ContainSubstring(`redis_keys_per_database_count{database="1"} 2`))
+			Expect(body).NotTo(ContainSubstring("redis_info_used_memory"))
+			Expect(body).To(ContainSubstring(`redis_exporter_section_errors_total{reason="timeout",section="Memory"} 1`))
+			Expect(fixture.metricsCollector.LastScrape().Err).To(HaveOccurred())
+		})
+	})
+
+	When("Every section query fails", func() {
+		BeforeEach(func() {
+			fixture.mockClient.EXPECT().Info(gomock.Any(), gomock.Any()).Return(redis.NewStringResult("", redis.ErrClosed)).Times(3)
+		})
+
+		It("Returns only the error metric", func() {
+			body := fixture.scrape()
+
+			Expect(body).NotTo(ContainSubstring("redis_clients_connected_total"))
+			Expect(body).To(ContainSubstring(`redis_exporter_section_errors_total{reason="error",section="Keyspace"} 1`))
+		})
+	})
+
+	When("Sections are queried in a pipeline", func() {
+		BeforeEach(func() {
+			fixture.metricsCollector.SetScrapeOptions(collector.ScrapeOptions{Pipeline: true})
+			fixture.metricsCollector.SetSectionOptions(map[string]collector.SectionOptions{
+				"Memory": {Interval: time.Hour},
+			})
+
+			// Cached section is left out of the second pipeline.
+			first := fixture.mockClient.EXPECT().Pipelined(gomock.Any(), gomock.Any()).Return([]redis.Cmder{
+				redis.NewStringResult("# Clients\nconnected_clients:3\n", nil),
+				redis.NewStringResult("# Memory\nused_memory:1024\n", nil),
+				redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil),
+			}, nil)
+			fixture.mockClient.EXPECT().Pipelined(gomock.Any(), gomock.Any()).Return([]redis.Cmder{
+				redis.NewStringResult("# Clients\nconnected_clients:4\n", nil),
+				redis.NewStringResult("", redis.ErrClosed),
+			}, redis.ErrClosed).After(first)
+		})
+
+		It("Sends one pipeline per scrape and keeps results of successful commands", func() {
+			first := fixture.scrape()
+			second := fixture.scrape()
+
+			Expect(first).To(ContainSubstring("redis_info_used_memory 1024"))
+			Expect(first).To(ContainSubstring(`redis_keys_per_database_count{database="1"} 2`))
+			Expect(second).To(ContainSubstring("redis_clients_connected_total 4"))
+			Expect(second).To(ContainSubstring("redis_info_used_memory 1024"))
+			Expect(second).To(ContainSubstring(`redis_exporter_section_errors_total{reason="error",section="Keyspace"} 1`))
+		})
+	})
+
+	When("Self metrics are enabled", func() {
+		BeforeEach(func() {
+			fixture.metricsCollector.EnableSelfMetrics()
+
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil))
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Memory").Return(redis.NewStringResult("# Memory\nused_memory:1024\n", nil))
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil))
+		})
+
+		It("Returns durations of section queries", func() {
+			body := fixture.scrape()
+
+			Expect(body).To(ContainSubstring(`redis_exporter_section_duration_seconds_count{section="Clients"} 1`))
+			Expect(body).To(ContainSubstring(`redis_exporter_section_duration_seconds_count{section="Keyspace"} 1`))
+		})
+	})
+
+	When("Relabeling rules and filters are set", func() {
+		BeforeEach(func() {
+			relabeler, err := relabel.New(
+				config.MetricFilters{Exclude: []string{"redis_info_.*", "redis_exporter_.*"}},
+				[]config.RelabelRule{{Metric: "redis_keys_per_database_count", Action: config.RelabelRename, Name: "redis_db_keys"}},
+			)
+			Expect(err).To(BeNil())
+			fixture.metricsCollector.SetRelabeler(relabeler)
+
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil))
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Memory").Return(redis.NewStringResult("# Memory\nused_memory:1024\n", nil))
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil))
+		})
+
+		It("Returns relabeled metrics which pass the filters", func() {
+			body := fixture.scrape()
+
+			Expect(body).To(ContainSubstring(`redis_db_keys{database="1"} 2`))
+			Expect(body).To(ContainSubstring("redis_clients_connected_total 3"))
+			Expect(body).NotTo(ContainSubstring("redis_keys_per_database_count"))
+			Expect(body).NotTo(ContainSubstring("redis_info_"))
+			Expect(body).NotTo(ContainSubstring("redis_exporter_section_errors_total"))
+		})
+	})
+
+	When("Collector settings are updated", func() {
+		BeforeEach(func() {
+			fixture.metricsCollector.SetSectionOptions(map[string]collector.SectionOptions{
+				"Memory": {Interval: time.Hour},
+			})
+
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil)).Times(2)
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)).Times(2)
+			fixture.mockClient.EXPECT().Info(gomock.Any(), "Memory").Return(redis.NewStringResult("# Memory\nused_memory:1024\n", nil)).Times(2)
+		})
+
+		It("Drops cached section results", func() {
+			fixture.scrape()
+
+			fixture.metricsCollector.UpdateSettings(fixture.mockClient, []string{"Clients", "Memory"}, []int{1})
+
+			Expect(fixture.scrape()).To(ContainSubstring("redis_info_used_memory 1024"))
+		})
+	})
+})
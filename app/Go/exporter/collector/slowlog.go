@@ -0,0 +1,115 @@
+package collector
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	registerCollector("slowlog", "Length of the slow log and the ID and duration of its last entry.", false, newSlowlogCollector)
+}
+
+// slowlog holds the slow log state fetched from Redis.
+type slowlog struct {
+	length int64
+	// ID and duration in microseconds of the last entry, valid when the log is not empty.
+	lastID       int64
+	lastDuration int64
+}
+
+// slowlogCollector returns the slow log state queried with SLOWLOG commands.
+type slowlogCollector struct {
+	length       *prometheus.Desc
+	lastID       *prometheus.Desc
+	lastDuration *prometheus.Desc
+}
+
+func newSlowlogCollector(constLabels prometheus.Labels) SubCollector {
+	return &slowlogCollector{
+		length: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "slowlog_length"),
+			"Total slowlog",
+			nil, constLabels,
+		),
+		lastID: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "slowlog_last_id"),
+			"Last id of slowlog",
+			nil, constLabels,
+		),
+		lastDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "last_slow_execution_duration_seconds"),
+			"The amount of time needed for last slow execution, in seconds",
+			nil, constLabels,
+		),
+	}
+}
+
+// Describe writes descs of slow log metrics, they are named the same in all naming schemes.
+func (c *slowlogCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.length
+	ch <- c.lastID
+	ch <- c.lastDuration
+}
+
+// Commands returns commands querying the length of the slow log and its last entry.
+func (c *slowlogCollector) Commands(databases []int) [][]interface{} {
+	return [][]interface{}{{"SLOWLOG", "LEN"}, {"SLOWLOG", "GET", 1}}
+}
+
+// Parse returns the length of the slow log and its last entry.
+func (c *slowlogCollector) Parse(cmds []*redis.Cmd) (interface{}, error) {
+	length, err := cmds[0].Int64()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := cmds[1].Result()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, ok := reply.([]interface{})
+	if !ok {
+		return nil, errors.New("unexpected SLOWLOG GET reply")
+	}
+
+	log := &slowlog{length: length, lastID: -1}
+	if len(entries) == 0 {
+		return log, nil
+	}
+
+	// Entry starts with the ID, timestamp and duration in microseconds.
+	entry, ok := entries[0].([]interface{})
+	if !ok || len(entry) < 3 {
+		return nil, errors.New("unexpected SLOWLOG GET reply")
+	}
+
+	log.lastID, ok = entry[0].(int64)
+	if !ok {
+		return nil, errors.New("unexpected SLOWLOG GET reply")
+	}
+
+	log.lastDuration, ok = entry[2].(int64)
+	if !ok {
+		return nil, errors.New("unexpected SLOWLOG GET reply")
+	}
+
+	return log, nil
+}
+
+// Update returns the fetched slow log state.
+func (c *slowlogCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	log, ok := scrape.Data.(*slowlog)
+	if !ok {
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.length, prometheus.GaugeValue, float64(log.length))
+	if log.lastID >= 0 {
+		ch <- prometheus.MustNewConstMetric(c.lastID, prometheus.GaugeValue, float64(log.lastID))
+		ch <- prometheus.MustNewConstMetric(c.lastDuration, prometheus.GaugeValue, float64(log.lastDuration)/1e6)
+	}
+
+	return nil
+}
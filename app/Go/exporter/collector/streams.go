@@ -0,0 +1,188 @@
+package collector
+
+import (
+	"errors"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"strconv"
+	"unicode/utf8"
+)
+
+func init() {
+	registerCollector("streams", "Length, consumer groups and pending messages of streams in configured databases.", false, newStreamsCollector)
+}
+
+// Maximum number of streams returned per database, it bounds both the script run time and the number of series.
+const streamsPerDatabase = 100
+
+// Scans databases passed after the limit for streams and returns rows of database, stream name,
+// length and rows of consumer group name, number of consumers and pending messages.
+// The database selected by the script doesn't change the one of the connection, SCAN with TYPE requires Redis 6.
+const streamsScript = `
+local function field(reply, name)
+	for i = 1, #reply, 2 do
+		if reply[i] == name then
+			return reply[i + 1]
+		end
+	end
+	return 0
+end
+
+local limit = tonumber(ARGV[1])
+local result = {}
+for n = 2, #ARGV do
+	local db = ARGV[n]
+	redis.call('SELECT', db)
+	local found = 0
+	local cursor = '0'
+	repeat
+		local reply = redis.call('SCAN', cursor, 'COUNT', 1000, 'TYPE', 'stream')
+		cursor = reply[1]
+		for _, key in ipairs(reply[2]) do
+			if found < limit then
+				found = found + 1
+				local stream = redis.call('XINFO', 'STREAM', key)
+				local groups = {}
+				for _, group in ipairs(redis.call('XINFO', 'GROUPS', key)) do
+					table.insert(groups, {field(group, 'name'), field(group, 'consumers'), field(group, 'pending')})
+				end
+				table.insert(result, {tonumber(db), key, field(stream, 'length'), groups})
+			end
+		end
+	until cursor == '0' or found >= limit
+end
+return result
+`
+
+// stream holds the state of a single stream in a database.
+type stream struct {
+	database int
+	name     string
+	length   int64
+	groups   []streamGroup
+}
+
+// streamGroup holds the state of a consumer group of a stream.
+type streamGroup struct {
+	name      string
+	consumers int64
+	pending   int64
+}
+
+// streamsCollector returns the state of streams found by a Lua script.
+type streamsCollector struct {
+	length         *prometheus.Desc
+	groups         *prometheus.Desc
+	groupConsumers *prometheus.Desc
+	groupPending   *prometheus.Desc
+}
+
+func newStreamsCollector(constLabels prometheus.Labels) SubCollector {
+	return &streamsCollector{
+		length: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "stream_length"),
+			"Number of entries in the stream.",
+			[]string{"database", "stream"}, constLabels,
+		),
+		groups: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "stream_groups"),
+			"Number of consumer groups of the stream.",
+			[]string{"database", "stream"}, constLabels,
+		),
+		groupConsumers: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "stream_group_consumers"),
+			"Number of consumers in the consumer group of the stream.",
+			[]string{"database", "stream", "group"}, constLabels,
+		),
+		groupPending: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "stream_group_messages_pending"),
+			"Number of messages delivered to the consumer group of the stream, but not acknowledged yet.",
+			[]string{"database", "stream", "group"}, constLabels,
+		),
+	}
+}
+
+// Describe writes descs of stream metrics, they are named the same in all naming schemes.
+func (c *streamsCollector) Describe(naming NamingScheme, ch chan<- *prometheus.Desc) {
+	ch <- c.length
+	ch <- c.groups
+	ch <- c.groupConsumers
+	ch <- c.groupPending
+}
+
+// Commands returns the script which finds streams of the databases.
+func (c *streamsCollector) Commands(databases []int) [][]interface{} {
+	return [][]interface{}{scriptCommand(streamsScript, streamsPerDatabase, databases)}
+}
+
+// Parse returns streams with their consumer groups.
+func (c *streamsCollector) Parse(cmds []*redis.Cmd) (interface{}, error) {
+	rows, err := replyRows(cmds[0], 4)
+	if err != nil {
+		return nil, err
+	}
+
+	streams := make([]stream, 0, len(rows))
+	for _, row := range rows {
+		database, databaseOk := row[0].(int64)
+		name, nameOk := row[1].(string)
+		length, lengthOk := row[2].(int64)
+		groups, groupsOk := row[3].([]interface{})
+		if !databaseOk || !nameOk || !lengthOk || !groupsOk {
+			return nil, errors.New("unexpected streams reply")
+		}
+
+		s := stream{database: int(database), name: name, length: length}
+		for _, item := range groups {
+			group, ok := item.([]interface{})
+			if !ok || len(group) < 3 {
+				return nil, errors.New("unexpected streams reply")
+			}
+
+			groupName, nameOk := group[0].(string)
+			consumers, consumersOk := group[1].(int64)
+			pending, pendingOk := group[2].(int64)
+			if !nameOk || !consumersOk || !pendingOk {
+				return nil, errors.New("unexpected streams reply")
+			}
+
+			s.groups = append(s.groups, streamGroup{name: groupName, consumers: consumers, pending: pending})
+		}
+
+		streams = append(streams, s)
+	}
+
+	return streams, nil
+}
+
+// Update returns the state of streams in the databases.
+// Streams and groups with names which are not valid UTF-8 can't be label values, so they are logged and left out.
+func (c *streamsCollector) Update(scrape *Scrape, ch chan<- prometheus.Metric) error {
+	streams, ok := scrape.Data.([]stream)
+	if !ok {
+		return nil
+	}
+
+	for _, s := range streams {
+		if !utf8.ValidString(s.name) {
+			scrape.Logger.Debug("Skipped stream with invalid UTF-8 name")
+			continue
+		}
+
+		db := strconv.Itoa(s.database)
+		ch <- prometheus.MustNewConstMetric(c.length, prometheus.GaugeValue, float64(s.length), db, s.name)
+		ch <- prometheus.MustNewConstMetric(c.groups, prometheus.GaugeValue, float64(len(s.groups)), db, s.name)
+
+		for _, group := range s.groups {
+			if !utf8.ValidString(group.name) {
+				scrape.Logger.Debug("Skipped consumer group with invalid UTF-8 name")
+				continue
+			}
+
+			ch <- prometheus.MustNewConstMetric(c.groupConsumers, prometheus.GaugeValue, float64(group.consumers), db, s.name, group.name)
+			ch <- prometheus.MustNewConstMetric(c.groupPending, prometheus.GaugeValue, float64(group.pending), db, s.name, group.name)
+		}
+	}
+
+	return nil
+}
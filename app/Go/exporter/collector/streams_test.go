@@ -0,0 +1,50 @@
+package collector_test
+
+import (
+	"context"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Redis streams", func() {
+	var fixture *collectorFixture
+
+	BeforeEach(func() {
+		fixture = newCollectorFixture(context.Background(), []string{"Clients"}, []int{0})
+		Expect(fixture.metricsCollector.SetCollectors([]string{"streams"})).To(Succeed())
+		fixture.metricsCollector.EnableSelfMetrics()
+
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("# Clients\nconnected_clients:3\n", nil))
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb0:keys=2,expires=0,avg_ttl=0\n", nil))
+	})
+
+	It("Returns length and consumer groups of streams of configured databases", func() {
+		fixture.mockClient.EXPECT().Do(gomock.Any(), "EVAL", gomock.Any(), 0, 100, 0).Return(redis.NewCmdResult([]interface{}{
+			[]interface{}{int64(0), "orders", int64(42), []interface{}{
+				[]interface{}{"billing", int64(2), int64(5)},
+				[]interface{}{"shipping", int64(1), int64(0)},
+			}},
+			[]interface{}{int64(0), "events", int64(0), []interface{}{}},
+			[]interface{}{int64(0), "invalid\xff", int64(1), []interface{}{}},
+		}, nil))
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_stream_length{database="0",stream="orders"} 42`))
+		Expect(body).To(ContainSubstring(`redis_stream_groups{database="0",stream="orders"} 2`))
+		Expect(body).To(ContainSubstring(`redis_stream_groups{database="0",stream="events"} 0`))
+		Expect(body).To(ContainSubstring(`redis_stream_group_consumers{database="0",group="billing",stream="orders"} 2`))
+		Expect(body).To(ContainSubstring(`redis_stream_group_messages_pending{database="0",group="billing",stream="orders"} 5`))
+		Expect(body).NotTo(ContainSubstring("invalid"))
+		Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="streams"} 1`))
+	})
+
+	It("Marks the collector as failed on an unexpected reply", func() {
+		fixture.mockClient.EXPECT().Do(gomock.Any(), "EVAL", gomock.Any(), 0, 100, 0).Return(redis.NewCmdResult([]interface{}{"orders"}, nil))
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="streams"} 0`))
+		Expect(body).NotTo(ContainSubstring("redis_stream_length"))
+	})
+})
@@ -0,0 +1,254 @@
+package collector
+
+import (
+	"fmt"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+	"sort"
+	"time"
+)
+
+// SubCollector builds a single family of Redis metrics, e.g. keyspace or slowlog metrics.
+// Sub-collectors are registered with registerCollector and enabled by name.
+type SubCollector interface {
+	// Describe writes descs of all metrics the sub-collector can send to the checked channel with the naming scheme.
+	Describe(naming NamingScheme, ch chan<- *prometheus.Desc)
+
+	// Update sends metrics built from the scrape to the channel.
+	// Metrics which can't be built are left out, the error marks the sub-collector as failed for the scrape.
+	// Metrics with descs which are not described, e.g. fields unknown to the catalog, are sent to Scrape.Unchecked.
+	Update(scrape *Scrape, ch chan<- prometheus.Metric) error
+}
+
+// Fetcher is implemented by sub-collectors which query Redis with their own commands.
+// Commands are sent together with INFO sections of the scrape, either by the worker pool or in the scrape pipeline,
+// so they are limited by the same timeouts unless the sub-collector has its own, see SetCollectorOptions. Commands get the configured
+// databases and are sent to the default one. Parse gets results of the commands in their order, it's called only
+// when all of them succeeded. Its result is passed to Update in Scrape.Data.
+type Fetcher interface {
+	Commands(databases []int) [][]interface{}
+	Parse(cmds []*redis.Cmd) (interface{}, error)
+}
+
+// Scrape holds Redis data of a single scrape or background poll shared by all sub-collectors.
+type Scrape struct {
+	// Fields of all queried INFO sections.
+	Info map[string]string
+	// Keyspace fields by database indexes, nil when the keyspace section failed.
+	// Redis doesn't list databases without keys, so configured Databases may be missing.
+	Keyspace  map[int]map[string]string
+	Databases []int
+
+	// Result of Parse of the sub-collector, nil for sub-collectors which don't fetch anything.
+	Data interface{}
+
+	Naming NamingScheme
+	Logger *zap.Logger
+
+	// Channel of metrics with descs which are not known before the scrape, they are collected by an unchecked collector.
+	Unchecked chan<- prometheus.Metric
+
+	droppedFields *prometheus.CounterVec
+	// Statuses of events observed by the collector by event names.
+	events map[string]eventStatus
+}
+
+// CollectorInfo describes a registered sub-collector.
+type CollectorInfo struct {
+	Name             string
+	Help             string
+	EnabledByDefault bool
+}
+
+// Returns a new sub-collector with descs of its metrics built with the constant labels.
+type collectorFactory func(constLabels prometheus.Labels) SubCollector
+
+type registeredCollector struct {
+	info    CollectorInfo
+	factory collectorFactory
+}
+
+// Registered sub-collectors by name.
+var collectorRegistry = make(map[string]registeredCollector)
+
+// Registers the sub-collector, it's called from init functions of sub-collector files.
+func registerCollector(name string, help string, enabledByDefault bool, factory collectorFactory) {
+	if _, ok := collectorRegistry[name]; ok {
+		panic(fmt.Sprintf("collector %q is registered twice", name))
+	}
+
+	collectorRegistry[name] = registeredCollector{
+		info:    CollectorInfo{Name: name, Help: help, EnabledByDefault: enabledByDefault},
+		factory: factory,
+	}
+}
+
+// AvailableCollectors returns all registered sub-collectors sorted by name.
+func AvailableCollectors() []CollectorInfo {
+	infos := make([]CollectorInfo, 0, len(collectorRegistry))
+	for _, c := range collectorRegistry {
+		infos = append(infos, c.info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+// DefaultCollectors returns names of sub-collectors enabled by default.
+func DefaultCollectors() []string {
+	var names []string
+	for _, info := range AvailableCollectors() {
+		if info.EnabledByDefault {
+			names = append(names, info.Name)
+		}
+	}
+
+	return names
+}
+
+// namedCollector is an enabled sub-collector.
+type namedCollector struct {
+	name      string
+	collector SubCollector
+}
+
+// Returns sub-collectors with the names sorted by name, unknown names are rejected.
+func newSubCollectors(names []string, constLabels prometheus.Labels) ([]namedCollector, error) {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+
+	collectors := make([]namedCollector, 0, len(sorted))
+	for i, name := range sorted {
+		c, ok := collectorRegistry[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown collector %q", name)
+		}
+		if i > 0 && sorted[i-1] == name {
+			continue
+		}
+
+		collectors = append(collectors, namedCollector{name: name, collector: c.factory(constLabels)})
+	}
+
+	return collectors, nil
+}
+
+// SetCollectors replaces enabled sub-collectors, unknown names are rejected and the current ones are kept.
+func (collector *MetricsCollector) SetCollectors(names []string) error {
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	subCollectors, err := newSubCollectors(names, collector.constLabels)
+	if err != nil {
+		return err
+	}
+
+	collector.collectorNames = names
+	collector.subCollectors = subCollectors
+
+	return nil
+}
+
+// Writes descs of enabled sub-collectors with the naming scheme.
+func (collector *MetricsCollector) describeSubCollectors(ch chan<- *prometheus.Desc) {
+	collector.mu.RLock()
+	subCollectors := collector.subCollectors
+	naming := collector.naming
+	collector.mu.RUnlock()
+
+	for _, sub := range subCollectors {
+		sub.collector.Describe(naming, ch)
+	}
+}
+
+// fetchResult holds the data fetched by a single sub-collector.
+type fetchResult struct {
+	data     interface{}
+	err      error
+	duration time.Duration
+}
+
+// namedFetcher is an enabled sub-collector which queries Redis with its own commands.
+type namedFetcher struct {
+	name    string
+	fetcher Fetcher
+}
+
+// Returns enabled sub-collectors which query Redis with their own commands.
+func fetchersOf(subCollectors []namedCollector) []namedFetcher {
+	var fetchers []namedFetcher
+	for _, sub := range subCollectors {
+		if fetcher, ok := sub.collector.(Fetcher); ok {
+			fetchers = append(fetchers, namedFetcher{name: sub.name, fetcher: fetcher})
+		}
+	}
+
+	return fetchers
+}
+
+// Returns the data parsed from results of the fetcher commands, the first command error fails the fetch.
+func parseFetched(fetcher Fetcher, cmds []*redis.Cmd) fetchResult {
+	for _, cmd := range cmds {
+		if cmd.Err() != nil {
+			return fetchResult{err: cmd.Err()}
+		}
+	}
+
+	data, err := fetcher.Parse(cmds)
+	return fetchResult{data: data, err: err}
+}
+
+// Updates all enabled sub-collectors with the snapshot and returns their success and duration.
+// Metrics with descs which are not described are sent to the unchecked channel.
+func (collector *MetricsCollector) updateSubCollectors(s *snapshot, ch chan<- prometheus.Metric, unchecked chan<- prometheus.Metric) {
+	collector.mu.RLock()
+	subCollectors := collector.subCollectors
+	naming := collector.naming
+	descs := collector.descs
+	selfMetrics := collector.selfMetrics
+	droppedFields := collector.droppedFields
+	events := collector.events
+	collector.mu.RUnlock()
+
+	statuses := events.status()
+	logger := collector.logger()
+	for _, sub := range subCollectors {
+		fetched := s.fetched[sub.name]
+
+		start := time.Now()
+		err := sub.collector.Update(&Scrape{
+			Info:      s.generalMetrics,
+			Keyspace:  s.keyspaceMetrics,
+			Databases: s.databases,
+			Data:      fetched.data,
+			Naming:    naming,
+			Logger:    logger.With(zap.String("collector", sub.name)),
+			Unchecked: unchecked,
+
+			droppedFields: droppedFields,
+			events:        statuses,
+		}, ch)
+		duration := fetched.duration + time.Since(start)
+
+		// Data which failed to be fetched is not passed to Update, so the fetch error takes precedence.
+		if fetched.err != nil {
+			err = fetched.err
+		}
+
+		success := 1.0
+		if err != nil {
+			success = 0
+			logger.Debug("Collector failed", zap.String("collector", sub.name), zap.Error(err))
+		}
+
+		// Success and duration are metrics of the exporter itself, so they don't change the output without self metrics.
+		if selfMetrics {
+			ch <- prometheus.MustNewConstMetric(descs.collectorSuccess, prometheus.GaugeValue, success, sub.name)
+			ch <- prometheus.MustNewConstMetric(descs.collectorDuration, prometheus.GaugeValue, duration.Seconds(), sub.name)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package collector_test
+
+import (
+	"context"
+	"errors"
+	"exporter/exporter/collector"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"time"
+)
+
+var _ = Describe("Redis sub-collectors", func() {
+	var fixture *collectorFixture
+
+	BeforeEach(func() {
+		fixture = newCollectorFixture(context.Background(), []string{"Replication"}, []int{1})
+
+		replication := "# Replication\nrole:master\nconnected_slaves:1\nslave0:ip=10.0.0.2,port=6379,state=online,offset=100,lag=1\n"
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Replication").Return(redis.NewStringResult(replication, nil)).AnyTimes()
+		fixture.mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil)).AnyTimes()
+	})
+
+	It("Lists registered collectors with the default ones", func() {
+		var names []string
+		for _, info := range collector.AvailableCollectors() {
+			names = append(names, info.Name)
+		}
+
+		Expect(names).To(Equal([]string{"cluster", "commandstats", "config", "dbsize", "derived", "events", "info", "key-sampling", "keyspace", "replication", "slowlog", "streams"}))
+		Expect(collector.DefaultCollectors()).To(Equal([]string{"info", "keyspace"}))
+	})
+
+	It("Returns metrics of default collectors without metrics of the exporter", func() {
+		body := fixture.scrape()
+
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{database="1"} 2`))
+		Expect(body).NotTo(ContainSubstring("redis_connected_slave_offset_bytes"))
+		Expect(body).NotTo(ContainSubstring("redis_exporter_collector_success"))
+		Expect(body).NotTo(ContainSubstring("redis_exporter_collector_duration_seconds"))
+	})
+
+	It("Returns metrics of enabled collectors with their success with self metrics", func() {
+		Expect(fixture.metricsCollector.SetCollectors([]string{"info", "keyspace", "replication"})).To(Succeed())
+		fixture.metricsCollector.EnableSelfMetrics()
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_connected_slave_offset_bytes{slave_ip="10.0.0.2",slave_port="6379",slave_state="online"} 100`))
+		Expect(body).To(ContainSubstring(`redis_connected_slave_lag_seconds{slave_ip="10.0.0.2",slave_port="6379",slave_state="online"} 1`))
+		Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="replication"} 1`))
+	})
+
+	It("Labels keyspace rows by their database indexes", func() {
+		fixture.metricsCollector.UpdateSettings(fixture.mockClient, []string{"Replication"}, []int{0, 1, 2})
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{database="0"} 0`))
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{database="1"} 2`))
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{database="2"} 0`))
+	})
+
+	It("Leaves out databases which are not configured", func() {
+		fixture.metricsCollector.UpdateSettings(fixture.mockClient, []string{"Replication"}, []int{3})
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{database="3"} 0`))
+		Expect(body).NotTo(ContainSubstring(`redis_keys_per_database_count{database="1"}`))
+	})
+
+	It("Leaves out metrics of disabled collectors", func() {
+		Expect(fixture.metricsCollector.SetCollectors([]string{"info"})).To(Succeed())
+
+		body := fixture.scrape()
+		Expect(body).To(ContainSubstring("redis_info_connected_slaves 1"))
+		Expect(body).NotTo(ContainSubstring("redis_keys_per_database_count"))
+		Expect(body).NotTo(ContainSubstring(`collector="keyspace"`))
+	})
+
+	It("Rejects unknown collectors and keeps the current ones", func() {
+		Expect(fixture.metricsCollector.SetCollectors([]string{"info", "hotkeys"})).NotTo(Succeed())
+		fixture.metricsCollector.EnableSelfMetrics()
+
+		Expect(fixture.scrape()).To(ContainSubstring(`redis_exporter_collector_success{collector="keyspace"} 1`))
+	})
+
+	It("Exposes durations of collectors with self metrics", func() {
+		fixture.metricsCollector.EnableSelfMetrics()
+
+		Expect(fixture.scrape()).To(ContainSubstring(`redis_exporter_collector_duration_seconds{collector="info"}`))
+	})
+
+	When("Collector queries Redis with its own commands", func() {
+		BeforeEach(func() {
+			Expect(fixture.metricsCollector.SetCollectors([]string{"info", "slowlog"})).To(Succeed())
+			fixture.metricsCollector.EnableSelfMetrics()
+		})
+
+		It("Returns metrics built from its data", func() {
+			fixture.mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "LEN").Return(redis.NewCmdResult(int64(3), nil))
+			fixture.mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "GET", 1).Return(redis.NewCmdResult([]interface{}{
+				[]interface{}{int64(12), int64(1607000000), int64(25000), []interface{}{"KEYS", "*"}},
+			}, nil))
+
+			body := fixture.scrape()
+			Expect(body).To(ContainSubstring("redis_slowlog_length 3"))
+			Expect(body).To(ContainSubstring("redis_slowlog_last_id 12"))
+			Expect(body).To(ContainSubstring("redis_last_slow_execution_duration_seconds 0.025"))
+			Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="slowlog"} 1`))
+		})
+
+		It("Limits commands of the collector with the section timeout", func() {
+			fixture.metricsCollector.SetScrapeOptions(collector.ScrapeOptions{SectionTimeout: time.Second})
+
+			hasDeadline := func(ctx context.Context, args ...interface{}) *redis.Cmd {
+				_, ok := ctx.Deadline()
+				Expect(ok).To(BeTrue())
+
+				return redis.NewCmdResult(int64(0), nil)
+			}
+			fixture.mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "LEN").DoAndReturn(hasDeadline)
+			fixture.mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "GET", 1).Return(redis.NewCmdResult([]interface{}{}, nil))
+
+			Expect(fixture.scrape()).To(ContainSubstring("redis_slowlog_length 0"))
+		})
+
+		It("Serves the cached collector data until the interval passes", func() {
+			Expect(fixture.metricsCollector.SetCollectorOptions(map[string]collector.SectionOptions{
+				"slowlog": {Interval: time.Hour, Timeout: time.Second},
+			})).To(Succeed())
+
+			// Commands are sent once with the timeout of the collector.
+			hasDeadline := func(ctx context.Context, args ...interface{}) *redis.Cmd {
+				deadline, ok := ctx.Deadline()
+				Expect(ok).To(BeTrue())
+				Expect(time.Until(deadline)).To(BeNumerically("<=", time.Second))
+
+				return redis.NewCmdResult(int64(2), nil)
+			}
+			fixture.mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "LEN").DoAndReturn(hasDeadline).Times(1)
+			fixture.mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "GET", 1).Return(redis.NewCmdResult([]interface{}{}, nil)).Times(1)
+
+			Expect(fixture.scrape()).To(ContainSubstring("redis_slowlog_length 2"))
+			Expect(fixture.scrape()).To(ContainSubstring("redis_slowlog_length 2"))
+		})
+
+		It("Leaves cached collector data out of the scrape pipeline", func() {
+			fixture.metricsCollector.SetScrapeOptions(collector.ScrapeOptions{Pipeline: true})
+			Expect(fixture.metricsCollector.SetCollectorOptions(map[string]collector.SectionOptions{
+				"slowlog": {Interval: time.Hour},
+			})).To(Succeed())
+
+			first := fixture.mockClient.EXPECT().Pipelined(gomock.Any(), gomock.Any()).Return([]redis.Cmder{
+				redis.NewStringResult("# Replication\nrole:master\n", nil),
+				redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil),
+				redis.NewCmdResult(int64(4), nil),
+				redis.NewCmdResult([]interface{}{}, nil),
+			}, nil)
+			fixture.mockClient.EXPECT().Pipelined(gomock.Any(), gomock.Any()).Return([]redis.Cmder{
+				redis.NewStringResult("# Replication\nrole:master\n", nil),
+				redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil),
+			}, nil).After(first)
+
+			fixture.scrape()
+			Expect(fixture.scrape()).To(ContainSubstring("redis_slowlog_length 4"))
+		})
+
+		It("Rejects options of unknown collectors", func() {
+			err := fixture.metricsCollector.SetCollectorOptions(map[string]collector.SectionOptions{"hotkeys": {Interval: time.Minute}})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Sends commands of the collector in the scrape pipeline", func() {
+			fixture.metricsCollector.SetScrapeOptions(collector.ScrapeOptions{Pipeline: true})
+
+			// Replication and Keyspace sections are followed by SLOWLOG commands.
+			fixture.mockClient.EXPECT().Pipelined(gomock.Any(), gomock.Any()).Return([]redis.Cmder{
+				redis.NewStringResult("# Replication\nrole:master\n", nil),
+				redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil),
+				redis.NewCmdResult(int64(4), nil),
+				redis.NewCmdResult([]interface{}{}, nil),
+			}, nil)
+
+			body := fixture.scrape()
+			Expect(body).To(ContainSubstring("redis_slowlog_length 4"))
+			Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="slowlog"} 1`))
+		})
+
+		It("Marks the collector as failed without failing the scrape", func() {
+			fixture.mockClient.EXPECT().Do(gomock.Any(), "SLOWLOG", "LEN").Return(redis.NewCmdResult(nil, errors.New("NOPERM")))
+
+			body := fixture.scrape()
+			Expect(body).To(ContainSubstring("redis_up 1"))
+			Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="slowlog"} 0`))
+			Expect(body).To(ContainSubstring(`redis_exporter_collector_success{collector="info"} 1`))
+			Expect(body).NotTo(ContainSubstring("redis_slowlog_length"))
+		})
+	})
+})
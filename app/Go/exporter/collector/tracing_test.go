@@ -0,0 +1,95 @@
+package collector_test
+
+import (
+	"context"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"exporter/exporter/tracing"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/label"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/export/trace/tracetest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Redis collector traces", func() {
+	var (
+		mockCtrl         *gomock.Controller
+		mockClient       *mocks.MockRedisClient
+		metricsCollector *collector.MetricsCollector
+		exporter         *tracetest.InMemoryExporter
+	)
+
+	// Returns exported spans by their names.
+	spans := func() map[string]*exporttrace.SpanData {
+		byName := make(map[string]*exporttrace.SpanData)
+		for _, span := range exporter.GetSpans() {
+			byName[span.Name] = span
+		}
+
+		return byName
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mocks.NewMockRedisClient(mockCtrl)
+
+		exporter = tracetest.NewInMemoryExporter()
+		otel.SetTracerProvider(sdktrace.NewTracerProvider(
+			sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+			sdktrace.WithSyncer(exporter),
+		))
+
+		metricsCollector = collector.NewMetricsCollector(context.Background(), mockClient, []string{"Clients"}, []int{1})
+		metricsCollector.SetTarget("redis:6379")
+	})
+
+	AfterEach(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	It("Traces the scrape of the target with a span per section", func() {
+		mockClient.EXPECT().Info(gomock.Any(), "Clients").Return(redis.NewStringResult("", redis.ErrClosed))
+		mockClient.EXPECT().Info(gomock.Any(), "Keyspace").Return(redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil))
+
+		handler := tracing.Handler(collector.NewHandler(metricsCollector, prometheus.NewRegistry()))
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+
+		root := spans()["GET /metrics"]
+		scrape := spans()["scrape"]
+		Expect(root).NotTo(BeNil())
+		Expect(scrape.ParentSpanID).To(Equal(root.SpanContext.SpanID))
+		Expect(scrape.Attributes).To(ContainElement(label.String("redis.target", "redis:6379")))
+
+		clients := spans()["INFO Clients"]
+		Expect(clients.ParentSpanID).To(Equal(scrape.SpanContext.SpanID))
+		Expect(clients.StatusCode).To(Equal(codes.Error))
+		Expect(spans()["INFO Keyspace"].StatusCode).To(Equal(codes.Unset))
+	})
+
+	It("Traces sections queried in a pipeline with a single span", func() {
+		metricsCollector.SetScrapeOptions(collector.ScrapeOptions{Pipeline: true})
+		mockClient.EXPECT().Pipelined(gomock.Any(), gomock.Any()).Return([]redis.Cmder{
+			redis.NewStringResult("# Clients\nconnected_clients:3\n", nil),
+			redis.NewStringResult("# Keyspace\ndb1:keys=2,expires=0,avg_ttl=0\n", nil),
+		}, nil)
+
+		rr := httptest.NewRecorder()
+		collector.NewHandler(metricsCollector, prometheus.NewRegistry()).ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(rr.Code).To(Equal(http.StatusOK))
+
+		pipeline := spans()["INFO pipeline"]
+		Expect(pipeline).NotTo(BeNil())
+		Expect(pipeline.ParentSpanID).To(Equal(spans()["scrape"].SpanContext.SpanID))
+		Expect(pipeline.Attributes).To(ContainElement(label.String("redis.sections", "Clients,Keyspace")))
+	})
+})
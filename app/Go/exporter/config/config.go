@@ -0,0 +1,412 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"github.com/spf13/viper"
+	"io/ioutil"
+	"strings"
+	"time"
+)
+
+// Known Redis INFO sections which can be requested with required_metrics.
+var knownSections = []string{
+	"Server",
+	"Clients",
+	"Memory",
+	"Persistence",
+	"Stats",
+	"Replication",
+	"CPU",
+	"Commandstats",
+	"Cluster",
+	"Keyspace",
+	"Modules",
+	"Errorstats",
+}
+
+// Supported naming schemes of Redis metrics.
+const (
+	NamingDefault   = "default"
+	NamingOliver006 = "oliver006"
+)
+
+// Supported TLS versions by their configuration names.
+var tlsVersions = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+	"TLS13": tls.VersionTLS13,
+}
+
+// Config declares connection and parser details.
+type Config struct {
+	ExporterPort string `mapstructure:"exporter_port"`
+
+	// HTTPS and client certificate settings of the exporter server, plain HTTP is served when not set.
+	WebConfigFile string `mapstructure:"web_config_file"`
+	// Client certificate of the healthcheck subcommand for servers which verify client certificates.
+	HealthcheckTLS HealthcheckTLSConfig `mapstructure:"healthcheck_tls"`
+
+	// Either host:port or redis://, rediss:// or unix:// URL.
+	RedisAddress  string `mapstructure:"redis_address"`
+	RedisUsername string `mapstructure:"redis_username"`
+	RedisPassword string `mapstructure:"redis_password"`
+
+	// Password is read from the file instead of redis_password when set.
+	RedisPasswordFile string `mapstructure:"redis_password_file"`
+	// YAML file with username and password per Redis address, takes precedence over other credentials.
+	RedisCredentialsFile string `mapstructure:"redis_credentials_file"`
+
+	RedisTLS TLSConfig `mapstructure:"redis_tls"`
+
+	// New connections are not attempted until the backoff delay after a failed one passes.
+	ReconnectBackoff BackoffConfig `mapstructure:"reconnect_backoff"`
+	// Scrapes of Redis which failed several times in a row are short-circuited for a while.
+	CircuitBreaker BreakerConfig `mapstructure:"circuit_breaker"`
+
+	RedisDatabases []int `mapstructure:"redis_databases"`
+
+	RequiredMetrics []Section `mapstructure:"required_metrics"`
+
+	// Maximum number of INFO sections queried concurrently during a scrape, the collector default is used when not set.
+	ScrapeConcurrency int `mapstructure:"scrape_concurrency"`
+	// Timeout of every section query without its own timeout, only the scrape timeout applies when not set.
+	SectionTimeout time.Duration `mapstructure:"section_timeout"`
+	// Sections are queried in a single pipeline per scrape, section_timeout limits the whole pipeline.
+	ScrapePipeline bool `mapstructure:"scrape_pipeline"`
+	// Refresh intervals and timeouts of sub-collectors which query Redis with their own commands.
+	CollectorOptions []CollectorOptions `mapstructure:"collector_options"`
+
+	// Redis is polled in background with the interval and scrapes are served from the last snapshot.
+	// Every scrape queries Redis when not set.
+	PollingInterval time.Duration `mapstructure:"polling_interval"`
+
+	// Labels added to every metric of Redis target, e.g. env, cluster or team.
+	Labels map[string]string `mapstructure:"labels"`
+	// Labels of single Redis instances, e.g. alias, they take precedence over global labels.
+	InstanceLabels []InstanceLabels `mapstructure:"instance_labels"`
+
+	// Names of Redis metrics, either default or oliver006 for names of oliver006/redis_exporter. Default when not set.
+	NamingScheme string `mapstructure:"naming_scheme"`
+
+	// Metrics of Redis are relabeled by the rules in their order, then filtered by their final names.
+	MetricFilters MetricFilters `mapstructure:"metric_filters"`
+	RelabelRules  []RelabelRule `mapstructure:"relabel_rules"`
+
+	// Exporter exposes its own build, runtime, Redis command and connection pool metrics.
+	SelfMetrics bool `mapstructure:"self_metrics"`
+
+	Log LogConfig `mapstructure:"log"`
+
+	// Scrapes, INFO section queries and Redis commands are traced when an exporter is set.
+	Tracing TracingConfig `mapstructure:"tracing"`
+
+	// Hash of the raw configuration file, used to tell loaded configurations apart.
+	Hash [sha256.Size]byte `mapstructure:"-"`
+}
+
+// TLSConfig declares TLS settings for connections to Redis.
+type TLSConfig struct {
+	// TLS is also enabled by the rediss:// scheme of the Redis address.
+	Enabled bool `mapstructure:"enabled"`
+
+	// System CA pool is used when not set.
+	CAFile string `mapstructure:"ca_file"`
+
+	// Client certificate and key for mutual TLS.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+
+	// Overrides the host name the server certificate is verified against.
+	ServerName string `mapstructure:"server_name"`
+
+	// One of TLS10, TLS11, TLS12 or TLS13, Go default is used when not set.
+	MinVersion string `mapstructure:"min_version"`
+}
+
+// HealthcheckTLSConfig declares the client certificate the healthcheck subcommand presents to the exporter.
+type HealthcheckTLSConfig struct {
+	// The server certificate is presented as the client certificate when not set.
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+}
+
+// BackoffConfig declares exponentially growing delays with jitter.
+type BackoffConfig struct {
+	Min time.Duration `mapstructure:"min"`
+	Max time.Duration `mapstructure:"max"`
+}
+
+// BreakerConfig declares the circuit breaker of Redis target.
+type BreakerConfig struct {
+	// Number of consecutive failed scrapes which opens the breaker, the breaker is disabled when set to 0.
+	FailureThreshold int `mapstructure:"failure_threshold"`
+
+	// Open breaker lets a probe scrape through after the duration, which grows up to the maximum while probes fail.
+	MinOpenDuration time.Duration `mapstructure:"min_open_duration"`
+	MaxOpenDuration time.Duration `mapstructure:"max_open_duration"`
+}
+
+// LogConfig declares the level, format and destination of exporter logs.
+type LogConfig struct {
+	// One of debug, info, warn or error.
+	Level string `mapstructure:"level"`
+	// One of console, json or logfmt.
+	Format string `mapstructure:"format"`
+	// Either stdout, stderr or a file path.
+	Output string `mapstructure:"output"`
+
+	Sampling SamplingConfig `mapstructure:"sampling"`
+}
+
+// SamplingConfig declares how many repeated log entries are written per second.
+// The first Initial entries with the same level and message are written, then every Thereafter-th of them.
+// Sampling is disabled when Initial is not set, Thereafter must be positive otherwise.
+type SamplingConfig struct {
+	Initial    int `mapstructure:"initial"`
+	Thereafter int `mapstructure:"thereafter"`
+}
+
+// TracingConfig declares where traces of scrapes and Redis commands are exported.
+type TracingConfig struct {
+	// Either otlp or stdout, tracing is disabled when not set.
+	Exporter string `mapstructure:"exporter"`
+	// Address of OTLP gRPC receiver, e.g. otel-collector:4317.
+	Endpoint string `mapstructure:"endpoint"`
+	// Traces are sent to OTLP receiver without TLS.
+	Insecure bool `mapstructure:"insecure"`
+	// Fraction of scrapes which are traced, from 0 to 1. Traces started by callers follow their sampling decision.
+	SampleRatio float64 `mapstructure:"sample_ratio"`
+}
+
+// Load reads the configuration file with the given name from the directory and validates it.
+// A new viper instance is used for every call, so the file can be re-read on reload.
+func Load(path string, name string) (*Config, error) {
+	v := viper.New()
+	v.AddConfigPath(path)
+	v.SetConfigName(name)
+
+	v.SetDefault("reconnect_backoff.min", 100*time.Millisecond)
+	v.SetDefault("reconnect_backoff.max", 30*time.Second)
+	v.SetDefault("circuit_breaker.failure_threshold", 3)
+	v.SetDefault("circuit_breaker.min_open_duration", time.Second)
+	v.SetDefault("circuit_breaker.max_open_duration", time.Minute)
+	v.SetDefault("naming_scheme", NamingDefault)
+	v.SetDefault("log.level", DefaultLogLevel)
+	v.SetDefault("log.format", DefaultLogFormat)
+	v.SetDefault("log.output", DefaultLogOutput)
+	v.SetDefault("tracing.endpoint", "localhost:4317")
+	v.SetDefault("tracing.sample_ratio", 1)
+
+	err := v.ReadInConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Default viper hooks are kept, the section hook allows plain section names in required_metrics.
+	cfg := &Config{}
+	err = v.Unmarshal(cfg, viper.DecodeHook(mapstructure.ComposeDecodeHookFunc(
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+		stringToSectionHookFunc(),
+	)))
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := ioutil.ReadFile(v.ConfigFileUsed())
+	if err != nil {
+		return nil, err
+	}
+	cfg.Hash = sha256.Sum256(raw)
+
+	// Database from the Redis URL is used when databases are not listed explicitly.
+	endpoint, err := cfg.RedisEndpoint()
+	if err == nil && len(cfg.RedisDatabases) == 0 && endpoint.Database >= 0 {
+		cfg.RedisDatabases = []int{endpoint.Database}
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration for values the exporter can't work with.
+func (cfg *Config) Validate() error {
+	if cfg.ExporterPort == "" {
+		return errors.New("exporter_port is not set")
+	}
+
+	if cfg.RedisAddress == "" {
+		return errors.New("redis_address is not set")
+	}
+
+	endpoint, err := cfg.RedisEndpoint()
+	if err != nil {
+		return err
+	}
+
+	if cfg.RedisPassword != "" && cfg.RedisPasswordFile != "" {
+		return errors.New("only one of redis_password and redis_password_file can be set")
+	}
+
+	if endpoint.Password != "" && (cfg.RedisPassword != "" || cfg.RedisPasswordFile != "") {
+		return errors.New("password is set both in redis_address and in redis_password or redis_password_file")
+	}
+
+	if (cfg.RedisTLS.CertFile == "") != (cfg.RedisTLS.KeyFile == "") {
+		return errors.New("both redis_tls cert_file and key_file must be set")
+	}
+
+	if (cfg.HealthcheckTLS.CertFile == "") != (cfg.HealthcheckTLS.KeyFile == "") {
+		return errors.New("both healthcheck_tls cert_file and key_file must be set")
+	}
+
+	_, err = ParseTLSVersion(cfg.RedisTLS.MinVersion)
+	if err != nil {
+		return err
+	}
+
+	if cfg.ReconnectBackoff.Min <= 0 || cfg.ReconnectBackoff.Max < cfg.ReconnectBackoff.Min {
+		return errors.New("reconnect_backoff min must be positive and not greater than max")
+	}
+
+	if cfg.CircuitBreaker.FailureThreshold < 0 {
+		return errors.New("circuit_breaker failure_threshold must not be negative")
+	}
+
+	if cfg.CircuitBreaker.FailureThreshold > 0 && (cfg.CircuitBreaker.MinOpenDuration <= 0 || cfg.CircuitBreaker.MaxOpenDuration < cfg.CircuitBreaker.MinOpenDuration) {
+		return errors.New("circuit_breaker min_open_duration must be positive and not greater than max_open_duration")
+	}
+
+	if len(cfg.RedisDatabases) == 0 {
+		return errors.New("redis_databases must contain at least one database")
+	}
+
+	seen := make(map[int]bool)
+	for _, db := range cfg.RedisDatabases {
+		if db < 0 {
+			return fmt.Errorf("redis_databases contains negative database %d", db)
+		}
+		if seen[db] {
+			return fmt.Errorf("redis_databases contains database %d more than once", db)
+		}
+		seen[db] = true
+	}
+
+	// Database from the Redis URL is only used when databases are not listed, so it must not contradict the list.
+	if endpoint.Database >= 0 && !seen[endpoint.Database] {
+		return fmt.Errorf("redis_address selects database %d, which is not listed in redis_databases", endpoint.Database)
+	}
+
+	if cfg.PollingInterval < 0 {
+		return errors.New("polling_interval must not be negative")
+	}
+
+	if cfg.ScrapeConcurrency < 0 {
+		return errors.New("scrape_concurrency must not be negative")
+	}
+
+	if cfg.SectionTimeout < 0 {
+		return errors.New("section_timeout must not be negative")
+	}
+
+	if len(cfg.RequiredMetrics) == 0 {
+		return errors.New("required_metrics must contain at least one section")
+	}
+
+	for _, section := range cfg.RequiredMetrics {
+		if !isKnownSection(section.Name) {
+			return fmt.Errorf("required_metrics contains unknown INFO section %q", section.Name)
+		}
+		if section.Interval < 0 || section.Timeout < 0 {
+			return fmt.Errorf("required_metrics section %s must not have negative interval or timeout", section.Name)
+		}
+	}
+
+	for i, options := range cfg.CollectorOptions {
+		if options.Name == "" {
+			return fmt.Errorf("collector_options entry %d requires collector", i)
+		}
+		if options.Interval < 0 || options.Timeout < 0 {
+			return fmt.Errorf("collector_options collector %s must not have negative interval or timeout", options.Name)
+		}
+	}
+
+	err = validateLabels(cfg.Labels)
+	if err != nil {
+		return fmt.Errorf("labels: %w", err)
+	}
+
+	for i, instance := range cfg.InstanceLabels {
+		err = instance.Validate()
+		if err != nil {
+			return fmt.Errorf("instance_labels entry %d: %w", i, err)
+		}
+	}
+
+	// Empty scheme is the default one.
+	if cfg.NamingScheme != "" && cfg.NamingScheme != NamingDefault && cfg.NamingScheme != NamingOliver006 {
+		return fmt.Errorf("naming_scheme must be either %s or %s, got %q", NamingDefault, NamingOliver006, cfg.NamingScheme)
+	}
+
+	err = cfg.MetricFilters.Validate()
+	if err != nil {
+		return err
+	}
+
+	for i, rule := range cfg.RelabelRules {
+		err = rule.Validate()
+		if err != nil {
+			return fmt.Errorf("relabel_rules entry %d: %w", i, err)
+		}
+	}
+
+	err = cfg.Log.Validate()
+	if err != nil {
+		return err
+	}
+
+	return cfg.Tracing.Validate()
+}
+
+// HashValue returns the configuration hash as a number which can be exposed as a metric value.
+// Only the first 6 bytes are used to fit into the float64 mantissa without losing precision.
+func (cfg *Config) HashValue() float64 {
+	b := make([]byte, 8)
+	copy(b[2:], cfg.Hash[:6])
+
+	return float64(binary.BigEndian.Uint64(b))
+}
+
+// ParseTLSVersion converts the configuration name of TLS version to its value, empty name is converted to 0.
+func ParseTLSVersion(name string) (uint16, error) {
+	if name == "" {
+		return 0, nil
+	}
+
+	version, ok := tlsVersions[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q", name)
+	}
+
+	return version, nil
+}
+
+func isKnownSection(section string) bool {
+	for _, v := range knownSections {
+		if strings.EqualFold(v, section) {
+			return true
+		}
+	}
+
+	return false
+}
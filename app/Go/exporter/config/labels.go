@@ -0,0 +1,57 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/prometheus/common/model"
+	"strings"
+)
+
+// InstanceLabels declares labels of a single Redis instance.
+type InstanceLabels struct {
+	// Redis address as set in redis_address, or its host:port or socket path.
+	Address string `mapstructure:"address"`
+	// Labels which are added to global labels, they take precedence over global labels with the same names.
+	Labels map[string]string `mapstructure:"labels"`
+}
+
+// TargetLabels returns global labels merged with labels of the configured Redis instance.
+func (cfg *Config) TargetLabels() map[string]string {
+	labels := make(map[string]string, len(cfg.Labels))
+	for k, v := range cfg.Labels {
+		labels[k] = v
+	}
+
+	endpoint, _ := cfg.RedisEndpoint()
+	for _, instance := range cfg.InstanceLabels {
+		if instance.Address != cfg.RedisAddress && instance.Address != endpoint.Address {
+			continue
+		}
+
+		for k, v := range instance.Labels {
+			labels[k] = v
+		}
+	}
+
+	return labels
+}
+
+// Checks that names of labels are valid Prometheus label names.
+func validateLabels(labels map[string]string) error {
+	for name := range labels {
+		if !model.LabelName(name).IsValid() || strings.HasPrefix(name, "__") {
+			return fmt.Errorf("invalid label name %q", name)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the instance address is set and names of its labels are valid.
+func (instance InstanceLabels) Validate() error {
+	if instance.Address == "" {
+		return errors.New("address is not set")
+	}
+
+	return validateLabels(instance.Labels)
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+)
+
+// Logs are written at info level to stderr in console format when not configured.
+const (
+	DefaultLogLevel  = "info"
+	DefaultLogFormat = "console"
+	DefaultLogOutput = "stderr"
+)
+
+// Supported log formats.
+var logFormats = []string{"console", "json", "logfmt"}
+
+// Validate checks the log settings, empty values are replaced with defaults and are valid.
+func (cfg LogConfig) Validate() error {
+	_, err := cfg.ParseLevel()
+	if err != nil {
+		return err
+	}
+
+	if !isLogFormat(cfg.Format) {
+		return fmt.Errorf("log format must be one of %v, got %q", logFormats, cfg.Format)
+	}
+
+	if cfg.Sampling.Initial < 0 {
+		return errors.New("log sampling initial must not be negative")
+	}
+
+	if cfg.Sampling.Initial > 0 && cfg.Sampling.Thereafter <= 0 {
+		return errors.New("log sampling thereafter must be positive when sampling is enabled")
+	}
+
+	return nil
+}
+
+// ParseLevel converts the configured level to the zap level, empty level is converted to the default one.
+func (cfg LogConfig) ParseLevel() (zapcore.Level, error) {
+	name := cfg.Level
+	if name == "" {
+		name = DefaultLogLevel
+	}
+
+	var level zapcore.Level
+	err := level.UnmarshalText([]byte(name))
+	if err != nil {
+		return level, fmt.Errorf("invalid log level %q", cfg.Level)
+	}
+
+	return level, nil
+}
+
+// WithDefaults returns the log settings with empty values replaced by defaults.
+func (cfg LogConfig) WithDefaults() LogConfig {
+	if cfg.Level == "" {
+		cfg.Level = DefaultLogLevel
+	}
+	if cfg.Format == "" {
+		cfg.Format = DefaultLogFormat
+	}
+	if cfg.Output == "" {
+		cfg.Output = DefaultLogOutput
+	}
+
+	return cfg
+}
+
+func isLogFormat(format string) bool {
+	if format == "" {
+		return true
+	}
+
+	for _, v := range logFormats {
+		if v == format {
+			return true
+		}
+	}
+
+	return false
+}
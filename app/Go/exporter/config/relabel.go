@@ -0,0 +1,151 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"github.com/prometheus/common/model"
+	"regexp"
+	"strings"
+)
+
+// References to captured groups in rename names, $$ is an escaped dollar sign.
+var groupReference = regexp.MustCompile(`\$\$|\$(\{\w+\}|\w+)`)
+
+// Prefixes of metrics the exporter serves next to the collector metrics, such as self metrics of the process and the Go runtime.
+var reservedMetricPrefixes = []string{"go_", "process_", "redis_exporter_"}
+
+// Supported relabeling actions.
+const (
+	RelabelRename     = "rename"
+	RelabelDropLabels = "drop_labels"
+	RelabelKeepLabels = "keep_labels"
+	RelabelReplace    = "replace"
+	RelabelAddLabels  = "add_labels"
+)
+
+// MetricFilters declares regular expressions matched against whole metric names.
+type MetricFilters struct {
+	// Only metrics matching any of expressions are kept, all metrics are kept when not set.
+	Include []string `mapstructure:"include"`
+	// Metrics matching any of expressions are dropped, even if they are included.
+	Exclude []string `mapstructure:"exclude"`
+}
+
+// RelabelRule declares a single change of metric names or labels.
+type RelabelRule struct {
+	// Regular expression matched against the whole metric name, the rule applies to all metrics when not set.
+	Metric string `mapstructure:"metric"`
+
+	// One of rename, drop_labels, keep_labels, replace or add_labels.
+	Action string `mapstructure:"action"`
+
+	// New name for rename, it can refer to groups of the metric expression, e.g. redis_${1}.
+	Name string `mapstructure:"name"`
+
+	// Labels which are dropped by drop_labels or the only ones kept by keep_labels.
+	Labels []string `mapstructure:"labels"`
+
+	// Value of the label is matched against the whole regex and replaced with the replacement by replace.
+	// The replacement can refer to groups of the regex, the label is dropped when the replacement is empty.
+	Label       string `mapstructure:"label"`
+	Regex       string `mapstructure:"regex"`
+	Replacement string `mapstructure:"replacement"`
+
+	// Labels with constant values which are set by add_labels.
+	Values map[string]string `mapstructure:"values"`
+}
+
+// Validate checks that all filter expressions compile.
+func (filters MetricFilters) Validate() error {
+	for _, expr := range append(append([]string{}, filters.Include...), filters.Exclude...) {
+		_, err := CompileAnchored(expr)
+		if err != nil {
+			return fmt.Errorf("metric_filters contains invalid expression %q: %w", expr, err)
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the rule has settings required by its action.
+func (rule RelabelRule) Validate() error {
+	_, err := CompileAnchored(rule.Metric)
+	if err != nil {
+		return fmt.Errorf("invalid metric expression %q: %w", rule.Metric, err)
+	}
+
+	switch rule.Action {
+	case RelabelRename:
+		if rule.Name == "" {
+			return errors.New("rename requires name")
+		}
+
+		// Group references are replaced by a valid name part, so only the rest of the name is validated.
+		if !model.IsValidMetricName(model.LabelValue(expandGroups(rule.Name))) {
+			return fmt.Errorf("rename requires a valid metric name, got %q", rule.Name)
+		}
+
+		// Renamed metrics would clash with metrics of the exporter in the response.
+		if IsReservedMetricName(expandGroups(rule.Name)) {
+			return fmt.Errorf("rename to %q clashes with metrics of the exporter itself", rule.Name)
+		}
+	case RelabelDropLabels, RelabelKeepLabels:
+		if len(rule.Labels) == 0 {
+			return fmt.Errorf("%s requires labels", rule.Action)
+		}
+	case RelabelReplace:
+		if !model.LabelName(rule.Label).IsValid() {
+			return fmt.Errorf("replace requires a valid label, got %q", rule.Label)
+		}
+
+		_, err = CompileAnchored(rule.Regex)
+		if err != nil {
+			return fmt.Errorf("invalid regex %q: %w", rule.Regex, err)
+		}
+	case RelabelAddLabels:
+		if len(rule.Values) == 0 {
+			return errors.New("add_labels requires values")
+		}
+
+		for name := range rule.Values {
+			if !model.LabelName(name).IsValid() {
+				return fmt.Errorf("add_labels contains invalid label %q", name)
+			}
+		}
+	default:
+		return fmt.Errorf("unknown action %q", rule.Action)
+	}
+
+	return nil
+}
+
+// CompileAnchored compiles the expression which has to match the whole string, empty expression matches everything.
+func CompileAnchored(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		expr = ".*"
+	}
+
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// IsReservedMetricName returns whether the name belongs to metrics which the exporter serves next to the collector metrics.
+func IsReservedMetricName(name string) bool {
+	for _, prefix := range reservedMetricPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Returns the name with references to captured groups replaced by a valid name part.
+func expandGroups(name string) string {
+	return groupReference.ReplaceAllStringFunc(name, func(reference string) string {
+		if reference == "$$" {
+			return "$"
+		}
+
+		return "x"
+	})
+}
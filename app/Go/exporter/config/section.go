@@ -0,0 +1,52 @@
+package config
+
+import (
+	"github.com/mitchellh/mapstructure"
+	"reflect"
+	"time"
+)
+
+// Section declares an INFO section from required_metrics with optional refresh interval and timeout.
+// Entries of required_metrics are either plain section names or maps with these settings.
+type Section struct {
+	Name string `mapstructure:"section"`
+
+	// Section is queried on every scrape when not set, otherwise the cached result is used until it's older than the interval.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Timeout of the section query, the scrape timeout applies when not set.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// CollectorOptions declares refresh interval and timeout of a sub-collector which queries Redis with its own commands,
+// e.g. slowlog or cluster.
+type CollectorOptions struct {
+	Name string `mapstructure:"collector"`
+
+	// Commands are sent on every scrape when not set, otherwise the cached result is used until it's older than the interval.
+	Interval time.Duration `mapstructure:"interval"`
+
+	// Timeout of the commands of the sub-collector, section_timeout applies when not set.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// SectionNames returns names of all required sections.
+func (cfg *Config) SectionNames() []string {
+	names := []string{}
+	for _, section := range cfg.RequiredMetrics {
+		names = append(names, section.Name)
+	}
+
+	return names
+}
+
+// Decodes plain section names from required_metrics into sections without settings.
+func stringToSectionHookFunc() mapstructure.DecodeHookFuncType {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.String || to != reflect.TypeOf(Section{}) {
+			return data, nil
+		}
+
+		return Section{Name: data.(string)}, nil
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Supported trace exporters.
+const (
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+// Validate checks the tracing settings, tracing without an exporter is disabled and valid.
+func (cfg TracingConfig) Validate() error {
+	switch cfg.Exporter {
+	case "", TracingExporterStdout:
+	case TracingExporterOTLP:
+		if cfg.Endpoint == "" {
+			return errors.New("tracing endpoint is required for the otlp exporter")
+		}
+	default:
+		return fmt.Errorf("tracing exporter must be either %s or %s, got %q", TracingExporterOTLP, TracingExporterStdout, cfg.Exporter)
+	}
+
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return errors.New("tracing sample_ratio must be between 0 and 1")
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"exporter/exporter/client"
+	"golang.org/x/sync/singleflight"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Checker tells whether the exporter is alive and ready to serve metrics.
+// Redis availability is checked with PING, the result is cached to not load Redis with frequent probes.
+type Checker struct {
+	client   func() client.RedisClient
+	cacheTTL time.Duration
+	timeout  time.Duration
+	pings    singleflight.Group
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	err       error
+}
+
+// NewChecker allocates a new checker for the client returned by the function.
+// Client is requested on every check, so the checker always uses the currently loaded configuration.
+func NewChecker(client func() client.RedisClient, cacheTTL time.Duration, timeout time.Duration) *Checker {
+	return &Checker{
+		client:   client,
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+	}
+}
+
+// Ready returns nil if Redis responds to PING.
+// Concurrent checks share a single PING, it's not cancelled together with the context of the caller.
+func (checker *Checker) Ready(ctx context.Context) error {
+	checker.mu.Lock()
+	if !checker.checkedAt.IsZero() && time.Since(checker.checkedAt) < checker.cacheTTL {
+		err := checker.err
+		checker.mu.Unlock()
+		return err
+	}
+	checker.mu.Unlock()
+
+	result := checker.pings.DoChan("ping", func() (interface{}, error) {
+		err := checker.ping()
+
+		// Cancelled PING doesn't tell whether Redis is reachable, so it's checked again by the next probe.
+		if !errors.Is(err, context.Canceled) {
+			checker.mu.Lock()
+			checker.err = err
+			checker.checkedAt = time.Now()
+			checker.mu.Unlock()
+		}
+
+		return nil, err
+	})
+
+	select {
+	case r := <-result:
+		return r.Err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Pings Redis with the current client, the PING is limited only by the timeout of the checker.
+func (checker *Checker) ping() error {
+	redisClient := checker.client()
+	if redisClient == nil {
+		return errors.New("no Redis client configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), checker.timeout)
+	defer cancel()
+
+	return redisClient.Ping(ctx).Err()
+}
+
+// Healthz responds with OK while the process is alive.
+func (checker *Checker) Healthz(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("OK"))
+}
+
+// Readyz responds with OK if the configuration is loaded and Redis is reachable.
+func (checker *Checker) Readyz(w http.ResponseWriter, r *http.Request) {
+	err := checker.Ready(r.Context())
+	if err != nil {
+		http.Error(w, "Redis is not reachable: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Write([]byte("OK"))
+}
@@ -0,0 +1,160 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"exporter/exporter/client"
+	"exporter/exporter/client/mocks"
+	"exporter/exporter/collector"
+	"exporter/exporter/health"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Exporter health checks", func() {
+	var (
+		mockCtrl   *gomock.Controller
+		mockClient *mocks.MockRedisClient
+		checker    *health.Checker
+	)
+
+	// Sends the request to the handler and returns the recorded response.
+	serve := func(handler http.HandlerFunc, path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("GET", path, nil))
+
+		return rr
+	}
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockClient = mocks.NewMockRedisClient(mockCtrl)
+
+		checker = health.NewChecker(func() client.RedisClient { return mockClient }, time.Minute, time.Second)
+	})
+
+	It("Reports liveness without checking Redis", func() {
+		rr := serve(checker.Healthz, "/healthz")
+
+		Expect(rr.Code).To(Equal(http.StatusOK))
+	})
+
+	When("Redis responds to PING", func() {
+		BeforeEach(func() {
+			mockClient.EXPECT().Ping(gomock.Any()).Return(redis.NewStatusResult("PONG", nil))
+		})
+
+		It("Reports readiness and caches the result", func() {
+			Expect(serve(checker.Readyz, "/readyz").Code).To(Equal(http.StatusOK))
+			// Mock expects a single PING, the second check uses the cached result.
+			Expect(serve(checker.Readyz, "/readyz").Code).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("Redis doesn't respond to PING", func() {
+		BeforeEach(func() {
+			mockClient.EXPECT().Ping(gomock.Any()).Return(redis.NewStatusResult("", errors.New("connection refused")))
+		})
+
+		It("Reports that exporter is not ready", func() {
+			rr := serve(checker.Readyz, "/readyz")
+
+			Expect(rr.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(rr.Body.String()).To(ContainSubstring("connection refused"))
+		})
+	})
+
+	When("Caller cancels the check", func() {
+		It("Finishes the PING and caches its result", func() {
+			pinged := make(chan struct{})
+			release := make(chan struct{})
+			mockClient.EXPECT().Ping(gomock.Any()).DoAndReturn(func(ctx context.Context) *redis.StatusCmd {
+				close(pinged)
+				<-release
+
+				// PING is limited by the timeout of the checker, not by the context of the caller.
+				Expect(ctx.Err()).To(BeNil())
+				_, ok := ctx.Deadline()
+				Expect(ok).To(BeTrue())
+
+				return redis.NewStatusResult("PONG", nil)
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			checked := make(chan error, 1)
+			go func() {
+				checked <- checker.Ready(ctx)
+			}()
+
+			Eventually(pinged).Should(BeClosed())
+			cancel()
+			Eventually(checked).Should(Receive(Equal(context.Canceled)))
+
+			// Other checks are not blocked by the running PING.
+			short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancelShort()
+			Expect(checker.Ready(short)).To(Equal(context.DeadlineExceeded))
+
+			close(release)
+			// Mock expects a single PING, the result of the cancelled check is cached.
+			Eventually(func() error { return checker.Ready(context.Background()) }).Should(Succeed())
+		})
+	})
+
+	When("PING is cancelled", func() {
+		BeforeEach(func() {
+			first := mockClient.EXPECT().Ping(gomock.Any()).Return(redis.NewStatusResult("", context.Canceled))
+			mockClient.EXPECT().Ping(gomock.Any()).Return(redis.NewStatusResult("PONG", nil)).After(first)
+		})
+
+		It("Doesn't cache the error", func() {
+			Expect(checker.Ready(context.Background())).To(Equal(context.Canceled))
+			Expect(checker.Ready(context.Background())).To(Succeed())
+		})
+	})
+
+	Describe("Landing page", func() {
+		var page *health.LandingPage
+
+		BeforeEach(func() {
+			mockClient.EXPECT().Ping(gomock.Any()).Return(redis.NewStatusResult("PONG", nil))
+
+			page = health.NewLandingPage(func() health.Status {
+				return health.Status{
+					Targets:   []string{"redis:6379"},
+					Sections:  []string{"Clients", "Memory"},
+					Databases: []int{1, 2},
+					LastScrape: collector.ScrapeStatus{
+						Time:     time.Now(),
+						Duration: 15 * time.Millisecond,
+						Err:      errors.New("i/o timeout"),
+					},
+				}
+			}, checker)
+		})
+
+		It("Lists targets, sections, last scrape status and links", func() {
+			rr := serve(page.ServeHTTP, "/")
+
+			Expect(rr.Code).To(Equal(http.StatusOK))
+			Expect(rr.Header().Get("Content-Type")).To(ContainSubstring("text/html"))
+			Expect(rr.Body.String()).To(ContainSubstring("<li>redis:6379</li>"))
+			Expect(rr.Body.String()).To(ContainSubstring("<li>Memory</li>"))
+			Expect(rr.Body.String()).To(ContainSubstring("Databases: 1, 2"))
+			Expect(rr.Body.String()).To(ContainSubstring("Ready: yes"))
+			Expect(rr.Body.String()).To(ContainSubstring("failed: i/o timeout"))
+			Expect(rr.Body.String()).To(ContainSubstring(`<a href="/metrics">`))
+		})
+
+		It("Responds with 404 for unknown paths", func() {
+			rr := serve(page.ServeHTTP, "/unknown")
+
+			Expect(rr.Code).To(Equal(http.StatusNotFound))
+		})
+	})
+})
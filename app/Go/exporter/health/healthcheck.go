@@ -0,0 +1,58 @@
+package health
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Check requests the health endpoint and returns an error unless it responds with 200 OK.
+// It's used by the healthcheck subcommand, so container images don't need curl.
+func Check(url string, tlsConfig *tls.Config, timeout time.Duration) error {
+	httpClient := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+	}
+
+	res, err := httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("health endpoint responded with %s", res.Status)
+	}
+
+	return nil
+}
+
+// PinnedTLSConfig returns client TLS configuration which trusts only the exporter's own certificate.
+// The exporter certificate can be self-signed and doesn't have to match localhost, so instead of
+// the host name verification the presented certificate is compared with the configured one.
+// The client certificate is presented in case the server requires one, no certificate is presented when it's nil.
+func PinnedTLSConfig(serverCertificate tls.Certificate, clientCertificate *tls.Certificate) *tls.Config {
+	tlsConfig := &tls.Config{
+		// Standard verification is replaced with pinning in VerifyPeerCertificate.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 || len(serverCertificate.Certificate) == 0 || !bytes.Equal(rawCerts[0], serverCertificate.Certificate[0]) {
+				return errors.New("server certificate doesn't match the configured certificate")
+			}
+
+			return nil
+		},
+	}
+
+	if clientCertificate != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCertificate}
+	}
+
+	return tlsConfig
+}
@@ -0,0 +1,105 @@
+package health_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"exporter/exporter/health"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var _ = Describe("Healthcheck command", func() {
+	var server *httptest.Server
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	When("Exporter serves plain HTTP", func() {
+		It("Succeeds for healthy exporter", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			Expect(health.Check(server.URL+"/healthz", nil, time.Second)).To(Succeed())
+		})
+
+		It("Fails for unhealthy exporter", func() {
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+
+			Expect(health.Check(server.URL+"/healthz", nil, time.Second)).NotTo(Succeed())
+		})
+	})
+
+	When("Exporter serves HTTPS", func() {
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		})
+
+		It("Succeeds with the exporter's own certificate pinned", func() {
+			certificate := server.TLS.Certificates[0]
+
+			Expect(health.Check(server.URL+"/healthz", health.PinnedTLSConfig(certificate, nil), time.Second)).To(Succeed())
+		})
+
+		It("Fails when the server presents another certificate", func() {
+			// Pin a modified copy of the server certificate.
+			certificate := server.TLS.Certificates[0]
+			raw := append([]byte{}, certificate.Certificate[0]...)
+			raw[len(raw)-1]++
+			certificate.Certificate = [][]byte{raw}
+
+			Expect(health.Check(server.URL+"/healthz", health.PinnedTLSConfig(certificate, nil), time.Second)).NotTo(Succeed())
+		})
+	})
+
+	When("Exporter requires client certificates", func() {
+		var presented chan []byte
+
+		BeforeEach(func() {
+			presented = make(chan []byte, 1)
+			server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				presented <- r.TLS.PeerCertificates[0].Raw
+			}))
+			server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+			server.StartTLS()
+		})
+
+		It("Presents the configured client certificate", func() {
+			client := newCertificate()
+
+			Expect(health.Check(server.URL+"/healthz", health.PinnedTLSConfig(server.TLS.Certificates[0], &client), time.Second)).To(Succeed())
+			Expect(<-presented).To(Equal(client.Certificate[0]))
+		})
+
+		It("Fails without a client certificate", func() {
+			Expect(health.Check(server.URL+"/healthz", health.PinnedTLSConfig(server.TLS.Certificates[0], nil), time.Second)).NotTo(Succeed())
+		})
+	})
+})
+
+// Returns a new self-signed certificate.
+func newCertificate() tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "healthcheck"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+
+	return tls.Certificate{Certificate: [][]byte{raw}, PrivateKey: key}
+}
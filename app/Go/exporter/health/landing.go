@@ -0,0 +1,98 @@
+package health
+
+import (
+	"exporter/exporter/collector"
+	"html/template"
+	"net/http"
+)
+
+// Status describes the exporter state shown on the landing page.
+type Status struct {
+	Targets    []string
+	Sections   []string
+	Databases  []int
+	LastScrape collector.ScrapeStatus
+}
+
+// Data passed to the landing page template.
+type landingPageData struct {
+	Status
+	Ready    bool
+	ReadyErr error
+}
+
+var landingPageTemplate = template.Must(template.New("landing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Redis Exporter</title>
+</head>
+<body>
+<h1>Redis Exporter</h1>
+<p>
+<a href="/metrics">Metrics</a> |
+<a href="/healthz">Health</a> |
+<a href="/readyz">Readiness</a>
+</p>
+<h2>Targets</h2>
+<ul>
+{{- range .Targets}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+<p>Databases: {{range $i, $db := .Databases}}{{if $i}}, {{end}}{{$db}}{{end}}</p>
+<p>Ready: {{if .Ready}}yes{{else}}no ({{.ReadyErr}}){{end}}</p>
+<h2>Enabled sections</h2>
+<ul>
+{{- range .Sections}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+<h2>Last scrape</h2>
+{{- if .LastScrape.Time.IsZero}}
+<p>No scrapes yet.</p>
+{{- else}}
+<p>Started: {{.LastScrape.Time.Format "2006-01-02T15:04:05Z07:00"}}</p>
+<p>Duration: {{.LastScrape.Duration}}</p>
+<p>Status: {{if .LastScrape.Err}}failed: {{.LastScrape.Err}}{{else}}success{{end}}</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// LandingPage serves HTML page with configured targets, enabled sections, last scrape status and links.
+type LandingPage struct {
+	status  func() Status
+	checker *Checker
+}
+
+// NewLandingPage allocates a new landing page, status is requested on every page view.
+func NewLandingPage(status func() Status, checker *Checker) *LandingPage {
+	return &LandingPage{
+		status:  status,
+		checker: checker,
+	}
+}
+
+// ServeHTTP renders the landing page.
+func (page *LandingPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Landing page is registered for "/", which matches every unknown path.
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+
+	err := page.checker.Ready(r.Context())
+
+	data := landingPageData{
+		Status:   page.status(),
+		Ready:    err == nil,
+		ReadyErr: err,
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	err = landingPageTemplate.Execute(w, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"exporter/exporter/config"
+	zaplogfmt "github.com/jsternberg/zap-logfmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+)
+
+// Encoders can be registered in zap only once per process.
+var registerLogfmt sync.Once
+
+// New builds a logger from the log settings, its level can be changed at runtime with the returned level.
+// Empty settings are replaced with defaults.
+func New(cfg config.LogConfig) (*zap.Logger, zap.AtomicLevel, error) {
+	cfg = cfg.WithDefaults()
+
+	level, err := cfg.ParseLevel()
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+	atomicLevel := zap.NewAtomicLevelAt(level)
+
+	registerLogfmt.Do(func() {
+		// Registration fails only when the name is taken, which is prevented by the once.
+		_ = zap.RegisterEncoder("logfmt", func(encoder zapcore.EncoderConfig) (zapcore.Encoder, error) {
+			return zaplogfmt.NewEncoder(encoder), nil
+		})
+	})
+
+	// Initialize the logs encoder.
+	encoder := zap.NewProductionEncoderConfig()
+	encoder.EncodeTime = zapcore.ISO8601TimeEncoder
+	encoder.EncodeDuration = zapcore.StringDurationEncoder
+
+	// Repeated entries are dropped only when sampling is configured.
+	var sampling *zap.SamplingConfig
+	if cfg.Sampling.Initial > 0 {
+		sampling = &zap.SamplingConfig{
+			Initial:    cfg.Sampling.Initial,
+			Thereafter: cfg.Sampling.Thereafter,
+		}
+	}
+
+	logger, err := zap.Config{
+		Level:            atomicLevel,
+		Encoding:         cfg.Format,
+		EncoderConfig:    encoder,
+		Sampling:         sampling,
+		OutputPaths:      []string{cfg.Output},
+		ErrorOutputPaths: []string{"stderr"},
+	}.Build()
+	if err != nil {
+		return nil, zap.AtomicLevel{}, err
+	}
+
+	return logger, atomicLevel, nil
+}
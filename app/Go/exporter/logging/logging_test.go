@@ -0,0 +1,101 @@
+package logging_test
+
+import (
+	"encoding/json"
+	"exporter/exporter/config"
+	"exporter/exporter/logging"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.uber.org/zap"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var _ = Describe("Logger", func() {
+	var (
+		dir    string
+		output string
+	)
+
+	// Returns lines written to the log file.
+	lines := func() []string {
+		data, err := ioutil.ReadFile(output)
+		Expect(err).To(BeNil())
+
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "logging")
+		Expect(err).To(BeNil())
+
+		output = filepath.Join(dir, "exporter.log")
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("Writes JSON entries with structured fields", func() {
+		logger, _, err := logging.New(config.LogConfig{Format: "json", Output: output})
+		Expect(err).To(BeNil())
+
+		logger.Info("Scraped Redis", zap.String("target", "redis:6379"))
+		logger.Sync()
+
+		entry := map[string]interface{}{}
+		Expect(json.Unmarshal([]byte(lines()[0]), &entry)).To(Succeed())
+		Expect(entry).To(HaveKeyWithValue("msg", "Scraped Redis"))
+		Expect(entry).To(HaveKeyWithValue("level", "info"))
+		Expect(entry).To(HaveKeyWithValue("target", "redis:6379"))
+	})
+
+	It("Writes logfmt entries", func() {
+		logger, _, err := logging.New(config.LogConfig{Format: "logfmt", Output: output})
+		Expect(err).To(BeNil())
+
+		logger.Info("Scraped Redis", zap.String("target", "redis:6379"))
+		logger.Sync()
+
+		Expect(lines()[0]).To(ContainSubstring("level=info"))
+		Expect(lines()[0]).To(ContainSubstring(`msg="Scraped Redis" target=redis:6379`))
+	})
+
+	It("Changes the level at runtime", func() {
+		logger, level, err := logging.New(config.LogConfig{Level: "warn", Format: "json", Output: output})
+		Expect(err).To(BeNil())
+
+		logger.Info("Dropped")
+		level.SetLevel(zap.DebugLevel)
+		logger.Debug("Written")
+		logger.Sync()
+
+		Expect(lines()).To(HaveLen(1))
+		Expect(lines()[0]).To(ContainSubstring("Written"))
+	})
+
+	It("Drops repeated entries when sampling is enabled", func() {
+		logger, _, err := logging.New(config.LogConfig{
+			Format:   "json",
+			Output:   output,
+			Sampling: config.SamplingConfig{Initial: 2, Thereafter: 100},
+		})
+		Expect(err).To(BeNil())
+
+		for i := 0; i < 10; i++ {
+			logger.Info("Repeated")
+		}
+		logger.Sync()
+
+		Expect(lines()).To(HaveLen(2))
+	})
+
+	It("Rejects invalid level", func() {
+		_, _, err := logging.New(config.LogConfig{Level: "verbose"})
+
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -3,6 +3,7 @@ package parser
 import (
 	"context"
 	"exporter/exporter/client"
+	"github.com/go-redis/redis/v8"
 	"strings"
 )
 
@@ -19,24 +20,45 @@ func GetInfoMetrics(ctx context.Context, requiredMetrics []string, client client
 		}
 
 		// Get Redis INFO data by querying it via client.
-		data := client.Info(ctx, section)
-
-		// Separate plain string of values into slice of strings.
-		// Fix for Windows line endings included (if ran locally in Windows).
-		slicedData := strings.Split(strings.Replace(data.String(), "\r\n", "\n", -1), "\n")
-
-		// Remove "# Clients" info section header from output, it is always first line and
-		// remove the trailing new line by dropping last element instead of iterating the whole slice.
-		slicedData = slicedData[1:]
-		slicedData = slicedData[:len(slicedData)-1]
-
-		// Add key-value entry to the metrics map.
-		for _, dataRow := range slicedData {
-			// Split string by ":" delimiter to separate the string into key and value.
-			parts := strings.Split(dataRow, ":")
-			metrics[parts[0]] = parts[1]
+		sectionMetrics, err := ParseInfoSection(client.Info(ctx, section))
+		if err != nil {
+			return nil, err
+		}
+
+		for k, v := range sectionMetrics {
+			metrics[k] = v
 		}
 	}
 
 	return &metrics, nil
 }
+
+// ParseInfoSection parses the result of INFO command for a single section, e.g. queued in a pipeline.
+func ParseInfoSection(data *redis.StringCmd) (map[string]string, error) {
+	if data.Err() != nil {
+		return nil, data.Err()
+	}
+
+	metrics := make(map[string]string)
+
+	// Separate plain string of values into slice of strings.
+	// Fix for Windows line endings included (if ran locally in Windows).
+	slicedData := strings.Split(strings.Replace(data.Val(), "\r\n", "\n", -1), "\n")
+
+	// Add key-value entry to the metrics map.
+	for _, dataRow := range slicedData {
+		// Skip section headers like "# Clients" and empty lines.
+		if dataRow == "" || strings.HasPrefix(dataRow, "#") {
+			continue
+		}
+
+		// Split string by the first ":" delimiter, so values may contain ":" too, malformed lines are skipped.
+		parts := strings.SplitN(dataRow, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		metrics[parts[0]] = parts[1]
+	}
+
+	return metrics, nil
+}
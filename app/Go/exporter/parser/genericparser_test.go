@@ -45,6 +45,36 @@ var _ = Describe("Generic INFO parser", func() {
 				Expect(reflect.DeepEqual(res, getGenericExpectedData())).To(BeTrue())
 			})
 		})
+
+		When("Lines are malformed or values contain colons", func() {
+			BeforeEach(func() {
+				serverResponse := redis.NewStringResult("# Server\r\nredis_version:6.0.9\r\nmalformed\r\nexecutable:C:\\redis\\redis-server.exe\r\nconfig_file:\r\n\r\n", nil)
+
+				mockClient.EXPECT().Info(ctx, "Server").Return(serverResponse)
+			})
+			It("Skips malformed lines and keeps whole values", func() {
+				res, err := parser.GetInfoMetrics(ctx, []string{"Server"}, mockClient)
+
+				Expect(err).To(BeNil())
+				Expect(*res).To(Equal(map[string]string{
+					"redis_version": "6.0.9",
+					"executable":    "C:\\redis\\redis-server.exe",
+					"config_file":   "",
+				}))
+			})
+		})
+
+		When("Redis query failed", func() {
+			BeforeEach(func() {
+				mockClient.EXPECT().Info(ctx, "Clients").Return(redis.NewStringResult("", context.DeadlineExceeded))
+			})
+			It("Returns the error", func() {
+				res, err := parser.GetInfoMetrics(ctx, requiredMetrics, mockClient)
+
+				Expect(err).To(Equal(context.DeadlineExceeded))
+				Expect(res).To(BeNil())
+			})
+		})
 	})
 })
 
@@ -3,46 +3,59 @@ package parser
 import (
 	"context"
 	"exporter/exporter/client"
+	"github.com/go-redis/redis/v8"
+	"strconv"
 	"strings"
 )
 
-func GetKeyspaceMetrics(ctx context.Context, client client.RedisClient) (*[]map[string]string, error) {
-	// Return array of maps with values per db.
-	metricsForAllDB := []map[string]string{}
-
+func GetKeyspaceMetrics(ctx context.Context, client client.RedisClient) (map[int]map[string]string, error) {
 	// Get Redis INFO keyspace section data by querying it via client.
-	data := client.Info(ctx, "Keyspace")
+	metricsPerDB, err := ParseKeyspace(client.Info(ctx, "Keyspace"))
+	if err != nil {
+		return nil, err
+	}
 
-	// Separate plain string of values into slice of strings.
-	// Fix for Windows line endings included (if ran locally in Windows).
-	slicedData := strings.Split(strings.Replace(data.String(), "\r\n", "\n", -1), "\n")
+	return metricsPerDB, nil
+}
 
-	// Remove the "db1:" part from the "db1:keys:=1..." response to ease the parsing logic.
-	for k, v := range slicedData {
-		slicedData[k] = strings.Join(strings.Split(v, ":")[1:], ":")
+// ParseKeyspace parses the result of INFO command for the keyspace section, e.g. queued in a pipeline.
+// Fields are returned by database indexes from "dbN" rows, Redis doesn't list databases without keys.
+func ParseKeyspace(data *redis.StringCmd) (map[int]map[string]string, error) {
+	if data.Err() != nil {
+		return nil, data.Err()
 	}
 
-	// Remove "# Keyspace" info section header from output, it is always first line and
-	// remove the trailing new line by dropping last element instead of iterating the whole slice.
-	slicedData = slicedData[1:]
-	slicedData = slicedData[:len(slicedData)-1]
+	// Return map of maps with values per db.
+	metricsPerDB := make(map[int]map[string]string)
 
-	// Iterate over keyspace data for each database.
-	for _, v := range slicedData {
-		metrics := make(map[string]string)
-		// Separate strings using comma separator.
-		separatedData := strings.Split(strings.Replace(v, ",", "\n", -1), "\n")
+	// Separate plain string of values into slice of strings.
+	// Fix for Windows line endings included (if ran locally in Windows).
+	slicedData := strings.Split(strings.Replace(data.Val(), "\r\n", "\n", -1), "\n")
+
+	// Iterate over keyspace data for each database, e.g. "db1:keys=1,expires=0,avg_ttl=0".
+	// Section header, empty and malformed lines are skipped.
+	for _, row := range slicedData {
+		parts := strings.SplitN(row, ":", 2)
+		if len(parts) != 2 || !strings.HasPrefix(parts[0], "db") {
+			continue
+		}
 
-		// Add key-value entry to the metrics map.
-		for _, dataRow := range separatedData {
+		db, err := strconv.Atoi(strings.TrimPrefix(parts[0], "db"))
+		if err != nil {
+			continue
+		}
+
+		metrics := make(map[string]string)
+		for _, pair := range strings.Split(parts[1], ",") {
 			// Split string by "=" delimiter to separate the string into key and value.
-			parts := strings.Split(dataRow, "=")
-			metrics[parts[0]] = parts[1]
+			kv := strings.SplitN(pair, "=", 2)
+			if len(kv) == 2 {
+				metrics[kv[0]] = kv[1]
+			}
 		}
 
-		// Append resulting slice with map of metrics per db.
-		metricsForAllDB = append(metricsForAllDB, metrics)
+		metricsPerDB[db] = metrics
 	}
 
-	return &metricsForAllDB, nil
+	return metricsPerDB, nil
 }
@@ -39,29 +39,42 @@ var _ = Describe("Keyspace INFO parser", func() {
 				Expect(reflect.DeepEqual(res, getKeyspaceExpectedData())).To(BeTrue())
 			})
 		})
-	})
-})
 
-func getKeyspaceExpectedData() *[]map[string]string {
-	metricsForAllDB := []map[string]string{}
+		When("Databases are missing or rows are malformed", func() {
+			BeforeEach(func() {
+				keyspaceResponse := redis.NewStringResult("# Keyspace\r\ndb3:keys=1,expires=0,avg_ttl=0\r\nmalformed\r\ndbx:keys=5\r\ndb12:keys=4,expires=1,avg_ttl=10,subexpiry\r\n", nil)
 
-	metrics := make(map[string]string)
-	metrics["avg_ttl"] = "0"
-	metrics["expires"] = "0"
-	metrics["keys"] = "2"
-	metricsForAllDB = append(metricsForAllDB, metrics)
+				mockClient.EXPECT().Info(ctx, "Keyspace").Return(keyspaceResponse)
+			})
+			It("Returns rows by their database indexes", func() {
+				res, err := parser.GetKeyspaceMetrics(ctx, mockClient)
 
-	metrics = make(map[string]string)
-	metrics["avg_ttl"] = "0"
-	metrics["expires"] = "0"
-	metrics["keys"] = "1"
-	metricsForAllDB = append(metricsForAllDB, metrics)
+				Expect(err).To(BeNil())
+				Expect(res).To(Equal(map[int]map[string]string{
+					3:  {"avg_ttl": "0", "expires": "0", "keys": "1"},
+					12: {"avg_ttl": "10", "expires": "1", "keys": "4"},
+				}))
+			})
+		})
 
-	metrics = make(map[string]string)
-	metrics["avg_ttl"] = "0"
-	metrics["expires"] = "0"
-	metrics["keys"] = "1"
-	metricsForAllDB = append(metricsForAllDB, metrics)
+		When("Redis query failed", func() {
+			BeforeEach(func() {
+				mockClient.EXPECT().Info(ctx, "Keyspace").Return(redis.NewStringResult("", context.DeadlineExceeded))
+			})
+			It("Returns the error", func() {
+				res, err := parser.GetKeyspaceMetrics(ctx, mockClient)
+
+				Expect(err).To(Equal(context.DeadlineExceeded))
+				Expect(res).To(BeNil())
+			})
+		})
+	})
+})
 
-	return &metricsForAllDB
+func getKeyspaceExpectedData() map[int]map[string]string {
+	return map[int]map[string]string{
+		1: {"avg_ttl": "0", "expires": "0", "keys": "2"},
+		2: {"avg_ttl": "0", "expires": "0", "keys": "1"},
+		3: {"avg_ttl": "0", "expires": "0", "keys": "1"},
+	}
 }
@@ -0,0 +1,311 @@
+package relabel
+
+import (
+	"exporter/exporter/config"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/model"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Relabeler changes names and labels of metrics by rules and drops metrics by their final names.
+type Relabeler struct {
+	rules   []rule
+	include []*regexp.Regexp
+	exclude []*regexp.Regexp
+}
+
+// rule is a compiled relabeling rule.
+type rule struct {
+	config.RelabelRule
+	metric *regexp.Regexp
+	regex  *regexp.Regexp
+}
+
+// sample holds the name and labels of a single metric while it's relabeled.
+type sample struct {
+	name   string
+	labels map[string]string
+}
+
+// New compiles the filters and the rules, nil relabeler is returned when there is nothing to apply.
+func New(filters config.MetricFilters, rules []config.RelabelRule) (*Relabeler, error) {
+	if len(filters.Include) == 0 && len(filters.Exclude) == 0 && len(rules) == 0 {
+		return nil, nil
+	}
+
+	r := &Relabeler{}
+	for _, expr := range filters.Include {
+		re, err := config.CompileAnchored(expr)
+		if err != nil {
+			return nil, err
+		}
+		r.include = append(r.include, re)
+	}
+
+	for _, expr := range filters.Exclude {
+		re, err := config.CompileAnchored(expr)
+		if err != nil {
+			return nil, err
+		}
+		r.exclude = append(r.exclude, re)
+	}
+
+	for _, cfg := range rules {
+		err := cfg.Validate()
+		if err != nil {
+			return nil, err
+		}
+
+		// Expressions are valid after validation.
+		metric, _ := config.CompileAnchored(cfg.Metric)
+		regex, _ := config.CompileAnchored(cfg.Regex)
+		r.rules = append(r.rules, rule{RelabelRule: cfg, metric: metric, regex: regex})
+	}
+
+	return r, nil
+}
+
+// Gatherer returns a gatherer which relabels and filters metric families of the gatherer,
+// the gatherer is returned as is by nil relabeler.
+func (r *Relabeler) Gatherer(gatherer prometheus.Gatherer) prometheus.Gatherer {
+	if r == nil {
+		return gatherer
+	}
+
+	return prometheus.GathererFunc(func() ([]*dto.MetricFamily, error) {
+		families, err := gatherer.Gather()
+
+		return r.Relabel(families), err
+	})
+}
+
+// Metrics returns relabeled and filtered metrics, the metrics are returned as is by nil relabeler.
+// Relabeled metrics get descs without constant labels, as the rules can change any label.
+func (r *Relabeler) Metrics(metrics []prometheus.Metric) ([]prometheus.Metric, error) {
+	if r == nil {
+		return metrics, nil
+	}
+
+	// Registry builds metric families of the metrics, it checks their consistency on the way.
+	registry := prometheus.NewRegistry()
+	err := registry.Register(metricList(metrics))
+	if err != nil {
+		return nil, err
+	}
+
+	families, err := registry.Gather()
+
+	var relabeled []prometheus.Metric
+	for _, family := range r.Relabel(families) {
+		for _, metric := range family.GetMetric() {
+			relabeled = append(relabeled, newFamilyMetric(family, metric))
+		}
+	}
+
+	return relabeled, err
+}
+
+// Relabel returns metric families with relabeled names and labels of their metrics, sorted by name.
+// Metrics which are filtered out are dropped, series which become duplicates after relabeling are returned only once.
+// Metrics renamed to a family of another type are dropped, as the family keeps the type of its first metric.
+func (r *Relabeler) Relabel(families []*dto.MetricFamily) []*dto.MetricFamily {
+	relabeled := make(map[string]*dto.MetricFamily)
+	seen := make(map[string]bool)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			s := r.apply(family.GetName(), metric)
+			if s == nil || seen[s.key()] {
+				continue
+			}
+
+			target, ok := relabeled[s.name]
+			if !ok {
+				target = &dto.MetricFamily{Name: stringPtr(s.name), Help: family.Help, Type: family.Type}
+				relabeled[s.name] = target
+			}
+			if target.GetType() != family.GetType() {
+				continue
+			}
+
+			seen[s.key()] = true
+			target.Metric = append(target.Metric, s.metric(metric))
+		}
+	}
+
+	result := make([]*dto.MetricFamily, 0, len(relabeled))
+	for _, family := range relabeled {
+		if len(family.Metric) > 0 {
+			result = append(result, family)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GetName() < result[j].GetName()
+	})
+
+	return result
+}
+
+// Returns the relabeled sample of the metric of the family, nil is returned when the metric is filtered out.
+func (r *Relabeler) apply(name string, metric *dto.Metric) *sample {
+	s := &sample{name: name, labels: make(map[string]string)}
+	for _, pair := range metric.GetLabel() {
+		s.labels[pair.GetName()] = pair.GetValue()
+	}
+
+	for _, rule := range r.rules {
+		if rule.metric.MatchString(s.name) {
+			rule.apply(s)
+		}
+	}
+
+	if !r.keep(s.name) {
+		return nil
+	}
+
+	return s
+}
+
+// Returns whether the metric with the name passes the filters.
+func (r *Relabeler) keep(name string) bool {
+	for _, re := range r.exclude {
+		if re.MatchString(name) {
+			return false
+		}
+	}
+
+	if len(r.include) == 0 {
+		return true
+	}
+
+	for _, re := range r.include {
+		if re.MatchString(name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Changes the sample according to the rule action.
+func (rule rule) apply(s *sample) {
+	switch rule.Action {
+	case config.RelabelRename:
+		// Names are validated with captured groups replaced, the metric keeps its name if the groups make it invalid.
+		// Names of the exporter metrics are rejected too, as they would clash with them in the response.
+		name := rule.metric.ReplaceAllString(s.name, rule.Name)
+		if model.IsValidMetricName(model.LabelValue(name)) && !config.IsReservedMetricName(name) {
+			s.name = name
+		}
+	case config.RelabelDropLabels:
+		for _, name := range rule.Labels {
+			delete(s.labels, name)
+		}
+	case config.RelabelKeepLabels:
+		kept := make(map[string]string)
+		for _, name := range rule.Labels {
+			if value, ok := s.labels[name]; ok {
+				kept[name] = value
+			}
+		}
+		s.labels = kept
+	case config.RelabelReplace:
+		value := s.labels[rule.Label]
+		if !rule.regex.MatchString(value) {
+			return
+		}
+
+		value = rule.regex.ReplaceAllString(value, rule.Replacement)
+		if value == "" {
+			delete(s.labels, rule.Label)
+		} else {
+			s.labels[rule.Label] = value
+		}
+	case config.RelabelAddLabels:
+		for name, value := range rule.Values {
+			s.labels[name] = value
+		}
+	}
+}
+
+// Returns a copy of the metric with labels of the sample sorted by name.
+func (s *sample) metric(metric *dto.Metric) *dto.Metric {
+	relabeled := *metric
+	relabeled.Label = make([]*dto.LabelPair, 0, len(s.labels))
+	for _, name := range s.labelNames() {
+		relabeled.Label = append(relabeled.Label, &dto.LabelPair{Name: stringPtr(name), Value: stringPtr(s.labels[name])})
+	}
+
+	return &relabeled
+}
+
+// Returns identity of the series, so duplicates created by relabeling can be told apart.
+func (s *sample) key() string {
+	pairs := []string{s.name}
+	for _, name := range s.labelNames() {
+		pairs = append(pairs, name+"="+strconv.Quote(s.labels[name]))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// Returns sorted label names of the sample.
+func (s *sample) labelNames() []string {
+	names := make([]string, 0, len(s.labels))
+	for name := range s.labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// metricList is an unchecked collector of the metrics.
+type metricList []prometheus.Metric
+
+// Describe writes no descriptors, so the collector is registered as unchecked.
+func (list metricList) Describe(ch chan<- *prometheus.Desc) {}
+
+// Collect sends the metrics.
+func (list metricList) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range list {
+		ch <- metric
+	}
+}
+
+// familyMetric is a relabeled metric of the family.
+type familyMetric struct {
+	desc   *prometheus.Desc
+	metric *dto.Metric
+}
+
+// Returns the metric of the family with the desc built from its name, help and labels.
+func newFamilyMetric(family *dto.MetricFamily, metric *dto.Metric) familyMetric {
+	labels := make([]string, 0, len(metric.GetLabel()))
+	for _, pair := range metric.GetLabel() {
+		labels = append(labels, pair.GetName())
+	}
+
+	return familyMetric{
+		desc:   prometheus.NewDesc(family.GetName(), family.GetHelp(), labels, nil),
+		metric: metric,
+	}
+}
+
+// Desc returns the desc of the relabeled metric.
+func (m familyMetric) Desc() *prometheus.Desc {
+	return m.desc
+}
+
+// Write copies the relabeled metric.
+func (m familyMetric) Write(out *dto.Metric) error {
+	*out = *m.metric
+	return nil
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
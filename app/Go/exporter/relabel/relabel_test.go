@@ -0,0 +1,215 @@
+package relabel_test
+
+import (
+	"exporter/exporter/config"
+	"exporter/exporter/relabel"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"net/http/httptest"
+)
+
+var _ = Describe("Relabeler", func() {
+	var (
+		memory   prometheus.Gauge
+		keys     *prometheus.GaugeVec
+		duration *prometheus.HistogramVec
+		metrics  prometheus.Collector
+	)
+
+	// Returns the exposition of metrics relabeled by the filters and the rules.
+	expose := func(filters config.MetricFilters, rules ...config.RelabelRule) string {
+		relabeler, err := relabel.New(filters, rules)
+		Expect(err).To(BeNil())
+
+		r := prometheus.NewPedanticRegistry()
+		r.MustRegister(metrics)
+
+		rr := httptest.NewRecorder()
+		promhttp.HandlerFor(relabeler.Gatherer(r), promhttp.HandlerOpts{}).ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(rr.Code).To(Equal(http.StatusOK), rr.Body.String())
+
+		return rr.Body.String()
+	}
+
+	BeforeEach(func() {
+		memory = prometheus.NewGauge(prometheus.GaugeOpts{Name: "redis_info_used_memory", Help: "Data gathered from Redis INFO."})
+		memory.Set(1024)
+
+		keys = prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "redis_keys_per_database_count", Help: "Number of keys per Redis database."}, []string{"database", "role"})
+		keys.WithLabelValues("1", "master").Set(2)
+		keys.WithLabelValues("2", "master").Set(3)
+
+		duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{Name: "redis_exporter_section_duration_seconds", Help: "Duration.", Buckets: []float64{1}}, []string{"section"})
+		duration.WithLabelValues("Clients").Observe(0.5)
+
+		metrics = collectors{memory, keys, duration}
+	})
+
+	It("Keeps only included metrics which are not excluded", func() {
+		body := expose(config.MetricFilters{
+			Include: []string{"redis_info_.*", "redis_keys_.*"},
+			Exclude: []string{"redis_keys_per_database_count"},
+		})
+
+		Expect(body).To(ContainSubstring("redis_info_used_memory 1024"))
+		Expect(body).NotTo(ContainSubstring("redis_keys_per_database_count"))
+		Expect(body).NotTo(ContainSubstring("redis_exporter_section_duration_seconds"))
+	})
+
+	It("Matches filters against final metric names", func() {
+		body := expose(
+			config.MetricFilters{Include: []string{"redis_memory_used_bytes"}},
+			config.RelabelRule{Metric: "redis_info_used_(.*)", Action: config.RelabelRename, Name: "redis_${1}_used_bytes"},
+			config.RelabelRule{Metric: "redis_used_memory_used_bytes", Action: config.RelabelRename, Name: "redis_memory_used_bytes"},
+		)
+
+		Expect(body).To(ContainSubstring("# HELP redis_memory_used_bytes Data gathered from Redis INFO."))
+		Expect(body).To(ContainSubstring("# TYPE redis_memory_used_bytes gauge"))
+		Expect(body).To(ContainSubstring("redis_memory_used_bytes 1024"))
+		Expect(body).NotTo(ContainSubstring("redis_info_used_memory"))
+	})
+
+	It("Drops, keeps, replaces and adds labels", func() {
+		body := expose(config.MetricFilters{},
+			config.RelabelRule{Metric: "redis_keys_.*", Action: config.RelabelDropLabels, Labels: []string{"role"}},
+			config.RelabelRule{Action: config.RelabelReplace, Label: "database", Regex: "(.+)", Replacement: "db${1}"},
+			config.RelabelRule{Action: config.RelabelAddLabels, Values: map[string]string{"team": "cache"}},
+			config.RelabelRule{Metric: "redis_exporter_.*", Action: config.RelabelKeepLabels, Labels: []string{"team"}},
+		)
+
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{database="db1",team="cache"} 2`))
+		Expect(body).To(ContainSubstring(`redis_info_used_memory{team="cache"} 1024`))
+		Expect(body).To(ContainSubstring(`redis_exporter_section_duration_seconds_bucket{team="cache",le="1"} 1`))
+		Expect(body).To(ContainSubstring(`redis_exporter_section_duration_seconds_count{team="cache"} 1`))
+	})
+
+	It("Sends series which become duplicates only once", func() {
+		body := expose(config.MetricFilters{},
+			config.RelabelRule{Metric: "redis_keys_.*", Action: config.RelabelDropLabels, Labels: []string{"database"}},
+		)
+
+		Expect(body).To(ContainSubstring(`redis_keys_per_database_count{role="master"}`))
+	})
+
+	It("Rejects invalid rules", func() {
+		_, err := relabel.New(config.MetricFilters{}, []config.RelabelRule{{Action: "hashmod"}})
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Rejects renames to invalid metric names", func() {
+		_, err := relabel.New(config.MetricFilters{}, []config.RelabelRule{
+			{Metric: "redis_info_(.*)", Action: config.RelabelRename, Name: "redis-${1}"},
+		})
+
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("Keeps names of metrics whose captured groups make the new name invalid", func() {
+		body := expose(config.MetricFilters{},
+			config.RelabelRule{Metric: "redis_info_used_memory(.*)", Action: config.RelabelRename, Name: "${1}"},
+		)
+
+		Expect(body).To(ContainSubstring("redis_info_used_memory 1024"))
+	})
+
+	It("Keeps the type of the family when metrics of other types are renamed to it", func() {
+		body := expose(config.MetricFilters{},
+			config.RelabelRule{Metric: "redis_exporter_section_duration_seconds", Action: config.RelabelRename, Name: "redis_section_duration_seconds"},
+			config.RelabelRule{Metric: "redis_info_used_memory", Action: config.RelabelRename, Name: "redis_section_duration_seconds"},
+		)
+
+		Expect(body).To(ContainSubstring("# TYPE redis_section_duration_seconds histogram"))
+		Expect(body).To(ContainSubstring(`redis_section_duration_seconds_count{section="Clients"} 1`))
+		Expect(body).NotTo(ContainSubstring("redis_section_duration_seconds 1024"))
+	})
+
+	It("Rejects renames to names of the exporter metrics", func() {
+		for _, name := range []string{"go_goroutines", "process_cpu_seconds_total", "redis_exporter_build_info"} {
+			_, err := relabel.New(config.MetricFilters{}, []config.RelabelRule{
+				{Metric: "redis_info_used_memory", Action: config.RelabelRename, Name: name},
+			})
+
+			Expect(err).To(HaveOccurred(), name)
+		}
+	})
+
+	It("Keeps names of metrics whose captured groups make the new name one of the exporter metrics", func() {
+		body := expose(config.MetricFilters{},
+			config.RelabelRule{Metric: "(redis)_info_used_memory", Action: config.RelabelRename, Name: "${1}_exporter_used_memory"},
+		)
+
+		Expect(body).To(ContainSubstring("redis_info_used_memory 1024"))
+		Expect(body).NotTo(ContainSubstring("redis_exporter_used_memory"))
+	})
+
+	It("Relabels collected metrics, so they can be collected by an unchecked collector", func() {
+		relabeler, err := relabel.New(config.MetricFilters{Exclude: []string{"redis_exporter_.*"}},
+			[]config.RelabelRule{
+				{Metric: "redis_info_used_(.*)", Action: config.RelabelRename, Name: "redis_${1}_used_bytes"},
+				{Action: config.RelabelAddLabels, Values: map[string]string{"team": "cache"}},
+			},
+		)
+		Expect(err).To(BeNil())
+
+		ch := make(chan prometheus.Metric)
+		go func() {
+			metrics.Collect(ch)
+			close(ch)
+		}()
+
+		var collected []prometheus.Metric
+		for metric := range ch {
+			collected = append(collected, metric)
+		}
+
+		relabeled, err := relabeler.Metrics(collected)
+		Expect(err).To(BeNil())
+		Expect(relabeled).To(HaveLen(3))
+
+		r := prometheus.NewPedanticRegistry()
+		r.MustRegister(metricList(relabeled))
+
+		rr := httptest.NewRecorder()
+		promhttp.HandlerFor(r, promhttp.HandlerOpts{}).ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(rr.Code).To(Equal(http.StatusOK), rr.Body.String())
+		Expect(rr.Body.String()).To(ContainSubstring(`redis_memory_used_bytes{team="cache"} 1024`))
+		Expect(rr.Body.String()).To(ContainSubstring(`redis_keys_per_database_count{database="2",role="master",team="cache"} 3`))
+	})
+
+	It("Returns nil relabeler when there is nothing to apply", func() {
+		relabeler, err := relabel.New(config.MetricFilters{}, nil)
+
+		Expect(err).To(BeNil())
+		Expect(relabeler).To(BeNil())
+	})
+})
+
+// metricList is an unchecked collector of the metrics.
+type metricList []prometheus.Metric
+
+func (list metricList) Describe(ch chan<- *prometheus.Desc) {}
+
+func (list metricList) Collect(ch chan<- prometheus.Metric) {
+	for _, metric := range list {
+		ch <- metric
+	}
+}
+
+// collectors collects metrics of all collectors in the list.
+type collectors []prometheus.Collector
+
+func (c collectors) Describe(ch chan<- *prometheus.Desc) {
+	for _, collector := range c {
+		collector.Describe(ch)
+	}
+}
+
+func (c collectors) Collect(ch chan<- prometheus.Metric) {
+	for _, collector := range c {
+		collector.Collect(ch)
+	}
+}
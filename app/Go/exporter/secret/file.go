@@ -0,0 +1,63 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// File caches the file content until the file is modified.
+// It's used for passwords and certificates which can be rotated while the exporter is running.
+type File struct {
+	path    string
+	mu      sync.Mutex
+	modTime time.Time
+	size    int64
+	data    []byte
+}
+
+// NewFile allocates a new cached file, the file is read on the first Read call.
+func NewFile(path string) *File {
+	return &File{path: path}
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Read returns the file content, the file is re-read only if its modification time or size changed.
+func (f *File) Read() ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.data != nil && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.data, nil
+	}
+
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.data = data
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+
+	return f.data, nil
+}
+
+// ReadOptional reads the file if it's set, nil file has no content.
+func ReadOptional(f *File) ([]byte, error) {
+	if f == nil {
+		return nil, nil
+	}
+
+	return f.Read()
+}
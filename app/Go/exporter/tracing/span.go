@@ -0,0 +1,28 @@
+package tracing
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Start starts a child span of the span in the context.
+// The context is returned as is when tracing is disabled, as the span carries nothing to pass to children.
+func Start(ctx context.Context, name string, options ...trace.SpanOption) (context.Context, trace.Span) {
+	spanCtx, span := tracer().Start(ctx, name, options...)
+	if !span.SpanContext().IsValid() {
+		return ctx, span
+	}
+
+	return spanCtx, span
+}
+
+// End records the error in the span if there is one and ends the span.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,68 @@
+package tracing
+
+import (
+	"context"
+	"exporter/exporter/config"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp"
+	"go.opentelemetry.io/otel/exporters/stdout"
+	"go.opentelemetry.io/otel/propagation"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/semconv"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Name of the exporter in traces, both as the service and as the instrumentation library.
+const serviceName = "redis-exporter"
+
+// Returns the tracer of the exporter, its spans are dropped until tracing is set up.
+func tracer() trace.Tracer {
+	return otel.Tracer(serviceName)
+}
+
+// Setup installs the global tracer provider which exports spans with the configured exporter.
+// The returned function flushes buffered spans and stops the exporter, nothing is installed when tracing is disabled.
+func Setup(cfg config.TracingConfig) (func(context.Context) error, error) {
+	if cfg.Exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithConfig(sdktrace.Config{
+			// Sampling decision of the caller is followed when the scrape request carries a trace.
+			DefaultSampler: sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio)),
+		}),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.ServiceNameKey.String(serviceName))),
+		sdktrace.WithBatcher(exporter),
+	)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Returns the span exporter by its configuration name.
+func newExporter(cfg config.TracingConfig) (exporttrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case config.TracingExporterOTLP:
+		options := []otlp.ExporterOption{otlp.WithAddress(cfg.Endpoint)}
+		if cfg.Insecure {
+			options = append(options, otlp.WithInsecure())
+		}
+
+		return otlp.NewExporter(options...)
+	case config.TracingExporterStdout:
+		return stdout.NewExporter(stdout.WithoutMetricExport())
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+}
@@ -0,0 +1,163 @@
+package tracing_test
+
+import (
+	"context"
+	"exporter/exporter/config"
+	"exporter/exporter/tracing"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	exporttrace "go.opentelemetry.io/otel/sdk/export/trace"
+	"go.opentelemetry.io/otel/sdk/export/trace/tracetest"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+)
+
+// rawCodec passes gRPC messages as raw bytes, so the stub doesn't need OTLP protobuf types.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	return *(v.(*[]byte)), nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*(v.(*[]byte)) = append([]byte{}, data...)
+	return nil
+}
+
+func (rawCodec) String() string {
+	return "proto"
+}
+
+// collectorStub records OTLP export requests.
+type collectorStub struct {
+	mu       sync.Mutex
+	methods  []string
+	payloads []byte
+}
+
+// Records the request and replies with an empty response, which is a valid export response.
+func (stub *collectorStub) handle(srv interface{}, stream grpc.ServerStream) error {
+	method, _ := grpc.MethodFromServerStream(stream)
+
+	var payload []byte
+	err := stream.RecvMsg(&payload)
+	if err != nil {
+		return err
+	}
+
+	stub.mu.Lock()
+	stub.methods = append(stub.methods, method)
+	stub.payloads = append(stub.payloads, payload...)
+	stub.mu.Unlock()
+
+	response := []byte{}
+	return stream.SendMsg(&response)
+}
+
+var _ = Describe("Tracing", func() {
+	AfterEach(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+	})
+
+	Describe("Request handler", func() {
+		var exporter *tracetest.InMemoryExporter
+
+		// Returns exported spans by their names.
+		spans := func() map[string]*exporttrace.SpanData {
+			byName := make(map[string]*exporttrace.SpanData)
+			for _, span := range exporter.GetSpans() {
+				byName[span.Name] = span
+			}
+
+			return byName
+		}
+
+		BeforeEach(func() {
+			exporter = tracetest.NewInMemoryExporter()
+			otel.SetTracerProvider(sdktrace.NewTracerProvider(
+				sdktrace.WithConfig(sdktrace.Config{DefaultSampler: sdktrace.AlwaysSample()}),
+				sdktrace.WithSyncer(exporter),
+			))
+			otel.SetTextMapPropagator(propagation.TraceContext{})
+		})
+
+		It("Starts the root span of the request with spans of the scrape as children", func() {
+			handler := tracing.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, span := tracing.Start(r.Context(), "scrape")
+				tracing.End(span, nil)
+
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+
+			root := spans()["GET /metrics"]
+			Expect(root).NotTo(BeNil())
+			Expect(root.SpanKind).To(Equal(trace.SpanKindServer))
+			Expect(spans()["scrape"].ParentSpanID).To(Equal(root.SpanContext.SpanID))
+		})
+
+		It("Continues the trace of the caller", func() {
+			handler := tracing.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			request := httptest.NewRequest("GET", "/metrics", nil)
+			request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+			handler.ServeHTTP(httptest.NewRecorder(), request)
+
+			root := spans()["GET /metrics"]
+			Expect(root.SpanContext.TraceID.String()).To(Equal("4bf92f3577b34da6a3ce929d0e0e4736"))
+			Expect(root.ParentSpanID.String()).To(Equal("00f067aa0ba902b7"))
+		})
+	})
+
+	Describe("Tracing setup", func() {
+		It("Keeps tracing disabled without an exporter", func() {
+			shutdown, err := tracing.Setup(config.TracingConfig{})
+			Expect(err).To(BeNil())
+
+			ctx := context.Background()
+			spanCtx, span := tracing.Start(ctx, "scrape")
+
+			Expect(spanCtx).To(Equal(ctx))
+			Expect(span.SpanContext().IsValid()).To(BeFalse())
+			Expect(shutdown(ctx)).To(Succeed())
+		})
+
+		It("Exports spans to OTLP receiver", func() {
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).To(BeNil())
+
+			stub := &collectorStub{}
+			server := grpc.NewServer(grpc.CustomCodec(rawCodec{}), grpc.UnknownServiceHandler(stub.handle))
+			go server.Serve(listener)
+			defer server.Stop()
+
+			shutdown, err := tracing.Setup(config.TracingConfig{
+				Exporter:    config.TracingExporterOTLP,
+				Endpoint:    listener.Addr().String(),
+				Insecure:    true,
+				SampleRatio: 1,
+			})
+			Expect(err).To(BeNil())
+
+			_, span := tracing.Start(context.Background(), "INFO Clients")
+			tracing.End(span, nil)
+
+			// Buffered spans are exported on shutdown.
+			Expect(shutdown(context.Background())).To(Succeed())
+
+			stub.mu.Lock()
+			defer stub.mu.Unlock()
+			Expect(stub.methods).To(ContainElement("/opentelemetry.proto.collector.trace.v1.TraceService/Export"))
+			Expect(string(stub.payloads)).To(ContainSubstring("INFO Clients"))
+		})
+	})
+})
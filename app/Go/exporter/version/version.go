@@ -0,0 +1,28 @@
+package version
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"runtime"
+)
+
+// Build details, they are set with -ldflags "-X exporter/exporter/version.Version=... -X exporter/exporter/version.Revision=...".
+var (
+	Version   = "dev"
+	Revision  = "unknown"
+	BuildDate = "unknown"
+)
+
+// NewCollector returns a collector of redis_exporter_build_info metric with build details as labels.
+func NewCollector() prometheus.Collector {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: "redis_exporter",
+		Name:      "build_info",
+		Help:      "Build details of the exporter, the value is always 1.",
+		ConstLabels: prometheus.Labels{
+			"version":   Version,
+			"revision":  Revision,
+			"builddate": BuildDate,
+			"goversion": runtime.Version(),
+		},
+	}, func() float64 { return 1 })
+}
@@ -0,0 +1,238 @@
+package web
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/bcrypt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Authentication policies which can be set per endpoint.
+const (
+	// Requests are not authenticated.
+	AuthNone = "none"
+	// Either basic auth or bearer token is required.
+	AuthAny = "any"
+	// Only basic auth is accepted.
+	AuthBasic = "basic"
+	// Only bearer token is accepted.
+	AuthBearer = "bearer"
+)
+
+// Reasons of failed authentication attempts.
+const (
+	reasonMissingCredentials = "missing_credentials"
+	reasonInvalidCredentials = "invalid_credentials"
+)
+
+// Authenticator checks credentials of HTTP requests according to endpoint policies.
+type Authenticator struct {
+	cfg      *Config
+	failures *prometheus.CounterVec
+
+	// Hashes of username and password pairs which were verified with bcrypt.
+	// Checking bcrypt hash on every scrape is expensive, successful results are cached.
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]bool
+}
+
+// NewAuthenticator allocates a new authenticator, nil configuration doesn't require authentication.
+func NewAuthenticator(cfg *Config) *Authenticator {
+	if cfg == nil {
+		cfg = &Config{}
+	}
+
+	return &Authenticator{
+		cfg: cfg,
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "redis_exporter",
+			Name:      "http_auth_failures_total",
+			Help:      "Total number of HTTP requests rejected because of missing or invalid credentials.",
+		}, []string{"reason"}),
+		verified: make(map[[sha256.Size]byte]bool),
+	}
+}
+
+// Handler wraps the handler to serve only authenticated requests.
+func (a *Authenticator) Handler(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := a.cfg.policy(r.URL.Path)
+		if policy == AuthNone {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		reason := a.authenticate(r, policy)
+		if reason != "" {
+			a.failures.WithLabelValues(reason).Inc()
+
+			if policy != AuthBearer {
+				w.Header().Set("WWW-Authenticate", `Basic realm="Redis exporter"`)
+			}
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// LoopbackOnly wraps the handler of the path, which changes the exporter, to serve only requests from loopback
+// addresses when the configuration doesn't require credentials for the path. So the endpoint is not open to the network
+// without authentication, requests of other hosts are rejected with 403.
+func (a *Authenticator) LoopbackOnly(path string, handler http.Handler) http.Handler {
+	if a.cfg.policy(path) != AuthNone {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopback(r.RemoteAddr) {
+			http.Error(w, "Endpoint is served only to loopback clients without configured authentication.", http.StatusForbidden)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// Returns whether the remote address of the request is a loopback one.
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Describe writes all authentication metric descriptors to the Prometheus desc channel.
+func (a *Authenticator) Describe(ch chan<- *prometheus.Desc) {
+	a.failures.Describe(ch)
+}
+
+// Collect returns the current state of authentication metrics.
+func (a *Authenticator) Collect(ch chan<- prometheus.Metric) {
+	a.failures.Collect(ch)
+}
+
+// Returns the reason of failed authentication or empty string if the request is authenticated.
+func (a *Authenticator) authenticate(r *http.Request, policy string) string {
+	header := r.Header.Get("Authorization")
+
+	if policy == AuthAny || policy == AuthBearer {
+		if strings.HasPrefix(header, "Bearer ") {
+			if a.checkBearerToken(strings.TrimPrefix(header, "Bearer ")) {
+				return ""
+			}
+			return reasonInvalidCredentials
+		}
+	}
+
+	if policy == AuthAny || policy == AuthBasic {
+		username, password, ok := r.BasicAuth()
+		if ok {
+			if a.checkBasicAuth(username, password) {
+				return ""
+			}
+			return reasonInvalidCredentials
+		}
+	}
+
+	return reasonMissingCredentials
+}
+
+func (a *Authenticator) checkBearerToken(token string) bool {
+	valid := false
+
+	// Compare with every token in constant time to not leak which token matched.
+	for _, t := range a.cfg.BearerTokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			valid = true
+		}
+	}
+
+	return valid
+}
+
+func (a *Authenticator) checkBasicAuth(username string, password string) bool {
+	hash, ok := a.cfg.BasicAuthUsers[username]
+	if !ok {
+		return false
+	}
+
+	key := sha256.Sum256([]byte(username + ":" + password + ":" + hash))
+
+	a.mu.Lock()
+	verified := a.verified[key]
+	a.mu.Unlock()
+
+	if verified {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false
+	}
+
+	a.mu.Lock()
+	a.verified[key] = true
+	a.mu.Unlock()
+
+	return true
+}
+
+// Returns authentication policy for the request path.
+func (cfg *Config) policy(path string) string {
+	if policy, ok := cfg.EndpointAuth[path]; ok {
+		return policy
+	}
+
+	if len(cfg.BasicAuthUsers) == 0 && len(cfg.BearerTokens) == 0 {
+		return AuthNone
+	}
+
+	return AuthAny
+}
+
+func (cfg *Config) validateAuth() error {
+	for username, hash := range cfg.BasicAuthUsers {
+		_, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return fmt.Errorf("password of basic auth user %q is not a valid bcrypt hash: %v", username, err)
+		}
+	}
+
+	for _, token := range cfg.BearerTokens {
+		if token == "" {
+			return fmt.Errorf("bearer_tokens contains empty token")
+		}
+	}
+
+	for path, policy := range cfg.EndpointAuth {
+		switch policy {
+		case AuthNone:
+		case AuthAny:
+			if len(cfg.BasicAuthUsers) == 0 && len(cfg.BearerTokens) == 0 {
+				return fmt.Errorf("endpoint %s requires authentication, but no credentials are configured", path)
+			}
+		case AuthBasic:
+			if len(cfg.BasicAuthUsers) == 0 {
+				return fmt.Errorf("endpoint %s requires basic auth, but basic_auth_users is empty", path)
+			}
+		case AuthBearer:
+			if len(cfg.BearerTokens) == 0 {
+				return fmt.Errorf("endpoint %s requires bearer token, but bearer_tokens is empty", path)
+			}
+		default:
+			return fmt.Errorf("endpoint %s has unknown authentication policy %q", path, policy)
+		}
+	}
+
+	return nil
+}
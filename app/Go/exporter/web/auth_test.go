@@ -0,0 +1,171 @@
+package web_test
+
+import (
+	"exporter/exporter/web"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+)
+
+var _ = Describe("HTTP authentication", func() {
+	var (
+		authenticator *web.Authenticator
+		handler       http.Handler
+		metrics       http.Handler
+	)
+
+	// Sends the request to the authenticated handler and returns the response code.
+	serve := func(req *http.Request) int {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		return rr.Code
+	}
+
+	// Returns exported authentication metrics.
+	scrape := func() string {
+		rr := httptest.NewRecorder()
+		metrics.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+
+		return rr.Body.String()
+	}
+
+	BeforeEach(func() {
+		hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		Expect(err).To(BeNil())
+
+		authenticator = web.NewAuthenticator(&web.Config{
+			BasicAuthUsers: map[string]string{"prometheus": string(hash)},
+			BearerTokens:   []string{"token"},
+			EndpointAuth: map[string]string{
+				"/healthz":  web.AuthNone,
+				"/-/reload": web.AuthBasic,
+			},
+		})
+
+		handler = authenticator.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		r := prometheus.NewRegistry()
+		r.MustRegister(authenticator)
+		metrics = promhttp.HandlerFor(r, promhttp.HandlerOpts{})
+	})
+
+	It("Accepts valid basic auth credentials", func() {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.SetBasicAuth("prometheus", "secret")
+
+		Expect(serve(req)).To(Equal(http.StatusOK))
+		// Cached result is used for the second request.
+		Expect(serve(req)).To(Equal(http.StatusOK))
+	})
+
+	It("Accepts valid bearer token", func() {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		req.Header.Set("Authorization", "Bearer token")
+
+		Expect(serve(req)).To(Equal(http.StatusOK))
+	})
+
+	It("Rejects requests without credentials and counts failures", func() {
+		Expect(serve(httptest.NewRequest("GET", "/metrics", nil))).To(Equal(http.StatusUnauthorized))
+
+		Expect(scrape()).To(ContainSubstring(`redis_exporter_http_auth_failures_total{reason="missing_credentials"} 1`))
+	})
+
+	It("Rejects invalid credentials and counts failures", func() {
+		basic := httptest.NewRequest("GET", "/metrics", nil)
+		basic.SetBasicAuth("prometheus", "wrong")
+		bearer := httptest.NewRequest("GET", "/metrics", nil)
+		bearer.Header.Set("Authorization", "Bearer wrong")
+
+		Expect(serve(basic)).To(Equal(http.StatusUnauthorized))
+		Expect(serve(bearer)).To(Equal(http.StatusUnauthorized))
+
+		Expect(scrape()).To(ContainSubstring(`redis_exporter_http_auth_failures_total{reason="invalid_credentials"} 2`))
+	})
+
+	It("Serves open endpoints without credentials", func() {
+		Expect(serve(httptest.NewRequest("GET", "/healthz", nil))).To(Equal(http.StatusOK))
+	})
+
+	It("Accepts only the authentication method allowed by endpoint policy", func() {
+		req := httptest.NewRequest("POST", "/-/reload", nil)
+		req.Header.Set("Authorization", "Bearer token")
+
+		Expect(serve(req)).To(Equal(http.StatusUnauthorized))
+
+		req.SetBasicAuth("prometheus", "secret")
+		Expect(serve(req)).To(Equal(http.StatusOK))
+	})
+
+	When("No credentials are configured", func() {
+		It("Serves all endpoints without authentication", func() {
+			handler = web.NewAuthenticator(nil).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			Expect(serve(httptest.NewRequest("GET", "/metrics", nil))).To(Equal(http.StatusOK))
+		})
+
+		It("Serves endpoints which change the exporter only to loopback clients", func() {
+			handler = web.NewAuthenticator(nil).LoopbackOnly("/-/reload", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			Expect(serve(httptest.NewRequest("POST", "/-/reload", nil))).To(Equal(http.StatusForbidden))
+
+			req := httptest.NewRequest("POST", "/-/reload", nil)
+			req.RemoteAddr = "127.0.0.1:41000"
+			Expect(serve(req)).To(Equal(http.StatusOK))
+
+			req.RemoteAddr = "[::1]:41000"
+			Expect(serve(req)).To(Equal(http.StatusOK))
+		})
+	})
+
+	It("Leaves endpoints which require credentials open to other hosts", func() {
+		handler = authenticator.Handler(authenticator.LoopbackOnly("/-/reload", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+
+		req := httptest.NewRequest("POST", "/-/reload", nil)
+		req.SetBasicAuth("prometheus", "secret")
+		Expect(serve(req)).To(Equal(http.StatusOK))
+	})
+
+	Describe("Loading authentication settings", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "auth")
+			Expect(err).To(BeNil())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		load := func(data string) error {
+			path := filepath.Join(dir, "web.yaml")
+			Expect(ioutil.WriteFile(path, []byte(data), 0600)).To(Succeed())
+
+			_, err := web.LoadConfig(path)
+			return err
+		}
+
+		It("Rejects plaintext passwords", func() {
+			Expect(load("basic_auth_users:\n  prometheus: secret\n")).NotTo(Succeed())
+		})
+
+		It("Rejects unknown policies and policies without credentials", func() {
+			Expect(load("bearer_tokens: [token]\nendpoint_auth:\n  /metrics: always\n")).NotTo(Succeed())
+			Expect(load("bearer_tokens: [token]\nendpoint_auth:\n  /metrics: basic\n")).NotTo(Succeed())
+		})
+
+		It("Accepts policies with configured credentials", func() {
+			Expect(load("bearer_tokens: [token]\nendpoint_auth:\n  /metrics: bearer\n  /healthz: none\n")).To(Succeed())
+		})
+	})
+})
@@ -0,0 +1,136 @@
+package web
+
+import (
+	"crypto/tls"
+	"exporter/exporter/config"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io/ioutil"
+)
+
+// Client authentication types by their configuration names.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"":                           tls.NoClientCert,
+	"NoClientCert":               tls.NoClientCert,
+	"RequestClientCert":          tls.RequestClientCert,
+	"RequireAnyClientCert":       tls.RequireAnyClientCert,
+	"VerifyClientCertIfGiven":    tls.VerifyClientCertIfGiven,
+	"RequireAndVerifyClientCert": tls.RequireAndVerifyClientCert,
+}
+
+// Config declares settings of the exporter HTTP server.
+type Config struct {
+	// Server runs plain HTTP when TLS settings are not set.
+	TLSServerConfig *TLSServerConfig `yaml:"tls_server_config"`
+
+	// Usernames with bcrypt hashes of their passwords.
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+	// Tokens accepted in "Authorization: Bearer" header.
+	BearerTokens []string `yaml:"bearer_tokens"`
+
+	// Authentication policy per request path, one of none, any, basic or bearer.
+	// Paths which are not listed require any of configured credentials.
+	EndpointAuth map[string]string `yaml:"endpoint_auth"`
+}
+
+// TLSServerConfig declares TLS settings of the exporter HTTP server.
+type TLSServerConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+
+	// Client certificate authentication, client certificates are verified against the CA file.
+	ClientAuthType string `yaml:"client_auth_type"`
+	ClientCAFile   string `yaml:"client_ca_file"`
+
+	// Versions are one of TLS10, TLS11, TLS12 or TLS13, Go defaults are used when not set.
+	MinVersion string `yaml:"min_version"`
+	MaxVersion string `yaml:"max_version"`
+
+	// Cipher suite names as defined by crypto/tls, Go defaults are used when not set.
+	CipherSuites             []string `yaml:"cipher_suites"`
+	PreferServerCipherSuites bool     `yaml:"prefer_server_cipher_suites"`
+}
+
+// LoadConfig reads and validates the web configuration file.
+func LoadConfig(path string) (*Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	err = yaml.UnmarshalStrict(data, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse web configuration file %s: %v", path, err)
+	}
+
+	if cfg.TLSServerConfig != nil {
+		err = cfg.TLSServerConfig.validate()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = cfg.validateAuth()
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (cfg *TLSServerConfig) validate() error {
+	if cfg.CertFile == "" || cfg.KeyFile == "" {
+		return fmt.Errorf("both cert_file and key_file must be set in tls_server_config")
+	}
+
+	clientAuth, ok := clientAuthTypes[cfg.ClientAuthType]
+	if !ok {
+		return fmt.Errorf("unknown client_auth_type %q", cfg.ClientAuthType)
+	}
+
+	// Client certificates can't be verified without the CA.
+	if cfg.ClientCAFile == "" && (clientAuth == tls.VerifyClientCertIfGiven || clientAuth == tls.RequireAndVerifyClientCert) {
+		return fmt.Errorf("client_ca_file must be set for client_auth_type %s", cfg.ClientAuthType)
+	}
+
+	_, err := config.ParseTLSVersion(cfg.MinVersion)
+	if err != nil {
+		return err
+	}
+
+	_, err = config.ParseTLSVersion(cfg.MaxVersion)
+	if err != nil {
+		return err
+	}
+
+	_, err = parseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Converts cipher suite names to their identifiers.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	available := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		available[suite.Name] = suite.ID
+	}
+
+	ids := []uint16{}
+	for _, name := range names {
+		id, ok := available[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
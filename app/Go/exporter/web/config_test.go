@@ -0,0 +1,56 @@
+package web_test
+
+import (
+	"exporter/exporter/web"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Web configuration", func() {
+	When("Configuration is valid", func() {
+		It("Returns parsed TLS settings", func() {
+			cfg, err := web.LoadConfig("./testdata/tls.yaml")
+
+			Expect(err).To(BeNil())
+			Expect(cfg.TLSServerConfig).To(Equal(&web.TLSServerConfig{
+				CertFile:       "server.crt",
+				KeyFile:        "server.key",
+				ClientAuthType: "RequireAndVerifyClientCert",
+				ClientCAFile:   "ca.crt",
+				MinVersion:     "TLS12",
+				CipherSuites:   []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"},
+			}))
+		})
+	})
+
+	When("Configuration contains unknown fields", func() {
+		It("Returns an error", func() {
+			_, err := web.LoadConfig("./testdata/unknown_field.yaml")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("Client certificates are verified without CA", func() {
+		It("Returns an error", func() {
+			_, err := web.LoadConfig("./testdata/missing_ca.yaml")
+
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	When("Configuration contains invalid TLS settings", func() {
+		It("Rejects unknown versions, cipher suites and client auth types", func() {
+			for _, cfg := range []web.TLSServerConfig{
+				{CertFile: "server.crt", KeyFile: "server.key", MinVersion: "TLS14"},
+				{CertFile: "server.crt", KeyFile: "server.key", CipherSuites: []string{"TLS_UNKNOWN"}},
+				{CertFile: "server.crt", KeyFile: "server.key", ClientAuthType: "Always"},
+				{CertFile: "server.crt"},
+			} {
+				_, err := web.NewTLSConfig(&cfg)
+
+				Expect(err).To(HaveOccurred())
+			}
+		})
+	})
+})
@@ -0,0 +1,143 @@
+package web
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"exporter/exporter/config"
+	"exporter/exporter/secret"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// ListenAndServe listens on the server address and serves requests with the web configuration.
+// Server runs plain HTTP when the configuration is not set or doesn't contain TLS settings.
+func ListenAndServe(server *http.Server, cfg *Config) error {
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		return err
+	}
+
+	return Serve(server, listener, cfg)
+}
+
+// Serve serves requests on the listener with the web configuration.
+func Serve(server *http.Server, listener net.Listener, cfg *Config) error {
+	if cfg == nil || cfg.TLSServerConfig == nil {
+		return server.Serve(listener)
+	}
+
+	tlsConfig, err := NewTLSConfig(cfg.TLSServerConfig)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	server.TLSConfig = tlsConfig
+
+	// Certificates are provided by the TLS configuration.
+	return server.ServeTLS(listener, "", "")
+}
+
+// NewTLSConfig returns server TLS configuration which re-reads certificate files on every handshake when they change,
+// so rotated certificates are used without restart.
+func NewTLSConfig(cfg *TLSServerConfig) (*tls.Config, error) {
+	err := cfg.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	source := &tlsSource{
+		cfg:      cfg,
+		certFile: secret.NewFile(cfg.CertFile),
+		keyFile:  secret.NewFile(cfg.KeyFile),
+	}
+	if cfg.ClientCAFile != "" {
+		source.clientCAFile = secret.NewFile(cfg.ClientCAFile)
+	}
+
+	// Fail on startup if certificates can't be loaded.
+	_, err = source.config()
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return source.config()
+		},
+	}, nil
+}
+
+// tlsSource builds server TLS configuration from the current content of certificate files.
+type tlsSource struct {
+	cfg          *TLSServerConfig
+	certFile     *secret.File
+	keyFile      *secret.File
+	clientCAFile *secret.File
+
+	mu     sync.Mutex
+	cached *tls.Config
+	// Content of the files the cached config was built from.
+	cert     []byte
+	key      []byte
+	clientCA []byte
+}
+
+func (source *tlsSource) config() (*tls.Config, error) {
+	cert, err := source.certFile.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := source.keyFile.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	clientCA, err := secret.ReadOptional(source.clientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	source.mu.Lock()
+	defer source.mu.Unlock()
+
+	if source.cached != nil && string(cert) == string(source.cert) && string(key) == string(source.key) && string(clientCA) == string(source.clientCA) {
+		return source.cached, nil
+	}
+
+	certificate, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load server certificate: %v", err)
+	}
+
+	// Settings were validated when the source was created.
+	minVersion, _ := config.ParseTLSVersion(source.cfg.MinVersion)
+	maxVersion, _ := config.ParseTLSVersion(source.cfg.MaxVersion)
+	cipherSuites, _ := parseCipherSuites(source.cfg.CipherSuites)
+
+	tlsConfig := &tls.Config{
+		Certificates:             []tls.Certificate{certificate},
+		ClientAuth:               clientAuthTypes[source.cfg.ClientAuthType],
+		MinVersion:               minVersion,
+		MaxVersion:               maxVersion,
+		CipherSuites:             cipherSuites,
+		PreferServerCipherSuites: source.cfg.PreferServerCipherSuites,
+	}
+
+	if clientCA != nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(clientCA) {
+			return nil, fmt.Errorf("no certificates found in client CA file %s", source.clientCAFile.Path())
+		}
+		tlsConfig.ClientCAs = pool
+	}
+
+	source.cached = tlsConfig
+	source.cert = cert
+	source.key = key
+	source.clientCA = clientCA
+
+	return tlsConfig, nil
+}
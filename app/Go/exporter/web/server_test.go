@@ -0,0 +1,162 @@
+package web_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"exporter/exporter/web"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var _ = Describe("Exporter HTTP server", func() {
+	var (
+		dir      string
+		listener net.Listener
+		server   *http.Server
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "web")
+		Expect(err).To(BeNil())
+
+		listener, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).To(BeNil())
+
+		server = &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		})}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(dir)
+	})
+
+	When("Web configuration is not set", func() {
+		It("Serves plain HTTP", func() {
+			go web.Serve(server, listener, nil)
+
+			res, err := http.Get("http://" + listener.Addr().String())
+
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+
+	When("TLS with client certificate authentication is configured", func() {
+		var (
+			serverCert tls.Certificate
+			clientCert tls.Certificate
+		)
+
+		BeforeEach(func() {
+			serverCert = writeCertificate(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), "localhost")
+			clientCert = writeCertificate(filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key"), "client")
+
+			cfg := "tls_server_config:\n" +
+				"  cert_file: " + filepath.Join(dir, "server.crt") + "\n" +
+				"  key_file: " + filepath.Join(dir, "server.key") + "\n" +
+				"  client_auth_type: RequireAndVerifyClientCert\n" +
+				"  client_ca_file: " + filepath.Join(dir, "ca.crt") + "\n"
+			Expect(ioutil.WriteFile(filepath.Join(dir, "web.yaml"), []byte(cfg), 0600)).To(Succeed())
+
+			webConfig, err := web.LoadConfig(filepath.Join(dir, "web.yaml"))
+			Expect(err).To(BeNil())
+
+			go web.Serve(server, listener, webConfig)
+		})
+
+		It("Accepts clients with certificates signed by the CA", func() {
+			res, err := newClient(serverCert, &clientCert).Get("https://localhost:" + port(listener))
+
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+
+		It("Rejects clients without certificates", func() {
+			_, err := newClient(serverCert, nil).Get("https://localhost:" + port(listener))
+
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Serves rotated server certificate", func() {
+			rotated := writeCertificate(filepath.Join(dir, "server.crt"), filepath.Join(dir, "server.key"), "localhost")
+			later := time.Now().Add(time.Minute)
+			Expect(os.Chtimes(filepath.Join(dir, "server.crt"), later, later)).To(Succeed())
+			Expect(os.Chtimes(filepath.Join(dir, "server.key"), later, later)).To(Succeed())
+
+			res, err := newClient(rotated, &clientCert).Get("https://localhost:" + port(listener))
+
+			Expect(err).To(BeNil())
+			Expect(res.StatusCode).To(Equal(http.StatusOK))
+		})
+	})
+})
+
+// Returns HTTP client which trusts only the server certificate and optionally presents the client certificate.
+func newClient(serverCert tls.Certificate, clientCert *tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AddCert(serverCert.Leaf)
+
+	tlsConfig := &tls.Config{RootCAs: pool}
+	if clientCert != nil {
+		tlsConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+
+	return &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, DisableKeepAlives: true},
+	}
+}
+
+func port(listener net.Listener) string {
+	_, p, err := net.SplitHostPort(listener.Addr().String())
+	Expect(err).To(BeNil())
+
+	return p
+}
+
+// Writes a self-signed certificate for the common name and its key, returns the written certificate.
+func writeCertificate(certFile string, keyFile string, commonName string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).To(BeNil())
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		DNSNames:              []string{commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	Expect(err).To(BeNil())
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	Expect(err).To(BeNil())
+
+	Expect(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)).To(Succeed())
+	Expect(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600)).To(Succeed())
+
+	leaf, err := x509.ParseCertificate(der)
+	Expect(err).To(BeNil())
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
@@ -5,9 +5,21 @@ go 1.14
 require (
 	github.com/go-redis/redis/v8 v8.4.0
 	github.com/golang/mock v1.4.4
+	github.com/jsternberg/zap-logfmt v1.2.0
+	github.com/mitchellh/mapstructure v1.1.2
 	github.com/onsi/ginkgo v1.14.2
 	github.com/onsi/gomega v1.10.3
 	github.com/prometheus/client_golang v0.9.3
+	github.com/prometheus/client_model v0.0.0-20190812154241-14fe0d1b01d4
+	github.com/prometheus/common v0.4.0
 	github.com/spf13/viper v1.7.1
+	go.opentelemetry.io/otel v0.14.0
+	go.opentelemetry.io/otel/exporters/otlp v0.14.0
+	go.opentelemetry.io/otel/exporters/stdout v0.14.0
+	go.opentelemetry.io/otel/sdk v0.14.0
 	go.uber.org/zap v1.10.0
+	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
+	golang.org/x/sync v0.0.0-20201020160332-67f06af15bc9
+	google.golang.org/grpc v1.32.0
+	gopkg.in/yaml.v2 v2.3.0
 )